@@ -0,0 +1,191 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"sync/atomic"
+)
+
+// catalogKey identifies the message catalog of a domain and locale.
+type catalogKey struct {
+	domain, locale string
+}
+
+// maxFailed is the maximum number of failed loads remembered.
+const maxFailed = 1024
+
+// eviction is a message catalog evicted from the cache.
+type eviction struct {
+	key  catalogKey
+	size int64
+}
+
+// cacheEntry tracks the usage of a loaded message catalog.
+type cacheEntry struct {
+	// used is the value of the use clock at the last lookup.
+	used atomic.Uint64
+	// size is the estimated memory used by the catalog in bytes.
+	size int64
+	// pinned catalogs can not be reloaded and are never evicted.
+	pinned bool
+	// evicted is set if the catalog has been evicted and must be reloaded on
+	// its next use.
+	evicted bool
+	// source is the locale identifier the catalog has been loaded for.
+	source string
+}
+
+// CacheStats are counters of the message catalog cache of a Locales.
+type CacheStats struct {
+	// Hits is the number of lookups served by a loaded message catalog.
+	Hits uint64
+	// Loads is the number of message catalogs loaded, including reloads.
+	Loads uint64
+	// Evictions is the number of message catalogs evicted.
+	Evictions uint64
+	// Catalogs is the number of currently loaded message catalogs.
+	Catalogs int
+	// Bytes is the estimated memory used by the currently loaded message
+	// catalogs.
+	Bytes int64
+}
+
+// CacheStats returns the counters of the message catalog cache.
+func (l *Locales) CacheStats() CacheStats {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	ret := CacheStats{
+		Hits:      l.hits.Load(),
+		Loads:     l.loads.Load(),
+		Evictions: l.evictions.Load(),
+	}
+	for _, entry := range l.cache {
+		if !entry.evicted {
+			ret.Catalogs++
+			ret.Bytes += entry.size
+		}
+	}
+	return ret
+}
+
+// size returns the estimated memory used by the translation in bytes.
+func (t *translation) size() int64 {
+	var ret int64
+	for key, forms := range t.msgs {
+		ret += int64(len(key.Singular)+len(key.Plural)) + 64
+		for _, form := range forms {
+			ret += int64(len(form)) + 24
+		}
+	}
+	return ret
+}
+
+// install installs the given translation for the given domain and locale and
+// evicts the least recently used catalogs if the limits are exceeded. Pinned
+// translations are never evicted. It returns the evicted catalogs. The caller
+// must hold the write lock.
+func (l *Locales) install(domain, locale, source string, tr *translation,
+	pinned bool) []eviction {
+	if l.translations == nil {
+		l.translations = make(map[string]map[string]*translation)
+	}
+	if _, ok := l.translations[domain]; !ok {
+		l.translations[domain] = make(map[string]*translation)
+	}
+	if l.cache == nil {
+		l.cache = make(map[catalogKey]*cacheEntry)
+	}
+	key := catalogKey{domain, locale}
+	delete(l.failed, key)
+	if entry := l.cache[key]; entry != nil && !entry.pinned && !entry.evicted {
+		l.catalogs--
+		l.bytes -= entry.size
+	}
+	entry := &cacheEntry{size: tr.size(), pinned: pinned, source: source}
+	entry.used.Store(l.clock.Add(1))
+	l.cache[key] = entry
+	l.translations[domain][locale] = tr
+	if !pinned {
+		l.catalogs++
+		l.bytes += entry.size
+	}
+	return l.evict(key)
+}
+
+// evict evicts the least recently used catalogs except the given one until
+// the limits are met and returns them. The caller must hold the write lock.
+func (l *Locales) evict(keep catalogKey) []eviction {
+	var evicted []eviction
+	for (l.MaxCatalogs > 0 && l.catalogs > l.MaxCatalogs) ||
+		(l.MaxBytes > 0 && l.bytes > l.MaxBytes) {
+		var oldest *cacheEntry
+		var oldestKey catalogKey
+		for key, entry := range l.cache {
+			if key == keep || entry.pinned || entry.evicted {
+				continue
+			}
+			if oldest == nil || entry.used.Load() < oldest.used.Load() {
+				oldest, oldestKey = entry, key
+			}
+		}
+		if oldest == nil {
+			break
+		}
+		delete(l.translations[oldestKey.domain], oldestKey.locale)
+		oldest.evicted = true
+		l.catalogs--
+		l.bytes -= oldest.size
+		l.evictions.Add(1)
+		evicted = append(evicted, eviction{oldestKey, oldest.size})
+	}
+	return evicted
+}
+
+// fail remembers that the catalog for the given key could not be loaded. If
+// too many loads failed, all of them are forgotten first. The caller must
+// hold the write lock.
+func (l *Locales) fail(key catalogKey, err error) {
+	if l.failed == nil || len(l.failed) >= maxFailed {
+		l.failed = make(map[catalogKey]error)
+	}
+	l.failed[key] = err
+}
+
+// Refresh makes Locales try again to load the message catalogs which could
+// not be loaded before, e.g. after installing them. Message catalogs are
+// only loaded once otherwise.
+func (l *Locales) Refresh() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.failed = nil
+}
+
+// touch marks the catalog of the given domain and locale as used. It returns
+// the cache entry if the catalog has been evicted and must be reloaded. The
+// caller must hold the read lock.
+func (l *Locales) touch(domain, locale string) *cacheEntry {
+	entry := l.cache[catalogKey{domain, locale}]
+	if entry == nil {
+		return nil
+	}
+	if entry.evicted {
+		return entry
+	}
+	l.hits.Add(1)
+	entry.used.Store(l.clock.Add(1))
+	return nil
+}
@@ -0,0 +1,142 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestCacheEviction(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for _, domain := range []string{"a", "b", "c"} {
+		fsys["de/LC_MESSAGES/"+domain+".mo"] = &fstest.MapFile{
+			Data: makeMO(map[string]string{"Message": domain + " Message"})}
+	}
+	locales := Locales{FS: fsys, MaxCatalogs: 2}
+	catalog := NewCatalog()
+	catalog.Add("Message", "Pinned Message")
+	if err := locales.Add("pinned", "de", catalog); err != nil {
+		t.Fatalf("Could not add catalog: %v", err)
+	}
+	locales.Use("a", "de")
+	locales.Use("b", "de")
+	if ret := locales.Singular("a", "de", "Message"); ret != "a Message" {
+		t.Errorf(`Translation should be "a Message", got %q`, ret)
+	}
+	// Evicts b, the least recently used catalog.
+	locales.Use("c", "de")
+	stats := locales.CacheStats()
+	if stats.Catalogs != 3 || stats.Evictions != 1 || stats.Loads != 3 ||
+		stats.Hits != 1 {
+		t.Errorf("Unexpected stats after eviction: %+v", stats)
+	}
+	if _, ok := locales.translations["b"]["de"]; ok {
+		t.Errorf("Catalog b should have been evicted")
+	}
+	// Reloads b transparently and evicts a.
+	if ret := locales.Singular("b", "de", "Message"); ret != "b Message" {
+		t.Errorf(`Translation should be "b Message", got %q`, ret)
+	}
+	if ret := locales.Singular("pinned", "de", "Message"); ret != "Pinned Message" {
+		t.Errorf(`Translation should be "Pinned Message", got %q`, ret)
+	}
+	stats = locales.CacheStats()
+	if stats.Catalogs != 3 || stats.Evictions != 2 || stats.Loads != 4 {
+		t.Errorf("Unexpected stats after reload: %+v", stats)
+	}
+	if stats.Hits != 2 {
+		t.Errorf("Hits should be 2, got %d", stats.Hits)
+	}
+	if stats.Bytes <= 0 {
+		t.Errorf("Bytes should be positive, got %d", stats.Bytes)
+	}
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for _, domain := range []string{"a", "b"} {
+		fsys["de/LC_MESSAGES/"+domain+".mo"] = &fstest.MapFile{
+			Data: makeMO(map[string]string{"Message": domain + " Message"})}
+	}
+	locales := Locales{FS: fsys, MaxBytes: 1}
+	locales.Use("a", "de")
+	locales.Use("b", "de")
+	stats := locales.CacheStats()
+	if stats.Catalogs != 1 || stats.Evictions != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if ret := locales.Singular("a", "de", "Message"); ret != "a Message" {
+		t.Errorf(`Translation should be "a Message", got %q`, ret)
+	}
+	if _, ok := locales.translations["b"]["de"]; ok {
+		t.Errorf("Catalog b should have been evicted")
+	}
+}
+
+func TestCacheFailedReload(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for _, domain := range []string{"a", "b"} {
+		fsys["de/LC_MESSAGES/"+domain+".mo"] = &fstest.MapFile{
+			Data: makeMO(map[string]string{"Message": domain + " Message"})}
+	}
+	attempts := 0
+	locales := Locales{FS: fsys, MaxCatalogs: 1,
+		OnLoad: func(domain, locale string, err error) { attempts++ }}
+	locales.Use("a", "de")
+	locales.Use("b", "de")
+	delete(fsys, "de/LC_MESSAGES/a.mo")
+	for i := 0; i < 5; i++ {
+		if ret := locales.Singular("a", "de", "Message"); ret != "Message" {
+			t.Errorf(`Translation should be "Message", got %q`, ret)
+		}
+	}
+	if attempts != 3 {
+		t.Errorf("Catalogs should have been loaded 3 times, got %d", attempts)
+	}
+}
+
+func TestCacheFailedLoad(t *testing.T) {
+	fsys := fstest.MapFS{}
+	attempts := 0
+	locales := New(WithFS(fsys), WithDomain("app"), WithLocale("en"),
+		WithFallbacks("de"), WithLoadHook(func(domain, locale string,
+			err error) {
+			attempts++
+		}))
+	for i := 0; i < 100; i++ {
+		locales.Translator("", "")
+		locales.Use("app", "pt-BR")
+	}
+	if attempts != 3 {
+		t.Errorf("Missing catalogs should have been loaded 3 times, got %d",
+			attempts)
+	}
+	fsys["en/LC_MESSAGES/app.mo"] = &fstest.MapFile{
+		Data: makeMO(map[string]string{"Message": "English Message"})}
+	if ret := locales.Translator("", "").Singular("Message"); ret != "Message" {
+		t.Errorf(`Translation should be "Message" before refresh, got %q`, ret)
+	}
+	locales.Refresh()
+	if ret := locales.Translator("", "").Singular("Message"); ret !=
+		"English Message" {
+		t.Errorf(`Translation should be "English Message", got %q`, ret)
+	}
+	if attempts != 5 {
+		t.Errorf("Catalogs should have been loaded 5 times, got %d", attempts)
+	}
+}
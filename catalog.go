@@ -0,0 +1,171 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Catalog is a message catalog built in memory, e.g. for tests or plugins. Use
+// Locales.Add to install it. The zero value is an empty catalog.
+type Catalog struct {
+	msgs map[message][][]byte
+	// header contains the fields of the header entry in order of insertion.
+	header []string
+	rule   *PluralRule
+}
+
+// NewCatalog returns an empty catalog.
+func NewCatalog() *Catalog {
+	return &Catalog{msgs: make(map[message][][]byte)}
+}
+
+// set sets the translations of the given message.
+func (c *Catalog) set(msg message, translations [][]byte) {
+	if c.msgs == nil {
+		c.msgs = make(map[message][][]byte)
+	}
+	c.msgs[msg] = translations
+}
+
+// Add adds a translation for the given message.
+func (c *Catalog) Add(msg, translation string) {
+	c.set(message{msg, ""}, [][]byte{[]byte(translation)})
+}
+
+// AddPlural adds translations for the given singular and plural message, one
+// for each plural form.
+func (c *Catalog) AddPlural(singular, plural string, translations ...string) {
+	forms := make([][]byte, len(translations))
+	for i, translation := range translations {
+		forms[i] = []byte(translation)
+	}
+	c.set(message{singular, plural}, forms)
+}
+
+// AddContext adds a translation for the given message in the given context.
+func (c *Catalog) AddContext(context, msg, translation string) {
+	c.Add(context+contextSeparator+msg, translation)
+}
+
+// AddContextPlural adds translations for the given singular and plural
+// message in the given context.
+func (c *Catalog) AddContextPlural(context, singular, plural string,
+	translations ...string) {
+	c.AddPlural(context+contextSeparator+singular, plural, translations...)
+}
+
+// SetHeader sets a field of the catalog's header entry, e.g. "Language". The
+// field "Plural-Forms" also sets the catalog's plural rule.
+func (c *Catalog) SetHeader(name, value string) error {
+	if name == "Plural-Forms" {
+		rule, err := ParsePluralForms(value)
+		if err != nil {
+			return err
+		}
+		c.rule = &rule
+	}
+	field := name + ": " + value
+	for i, line := range c.header {
+		if strings.HasPrefix(line, name+":") {
+			c.header[i] = field
+			return nil
+		}
+	}
+	c.header = append(c.header, field)
+	return nil
+}
+
+// SetPluralRule sets the plural rule of the catalog. If none is set, the
+// plural rule of a message catalog loaded for the same domain and locale or
+// the standard one of the locale's language is used.
+func (c *Catalog) SetPluralRule(rule PluralRule) {
+	c.rule = &rule
+	for i, line := range c.header {
+		if strings.HasPrefix(line, "Plural-Forms:") {
+			c.header[i] = "Plural-Forms: " + rule.String()
+			return
+		}
+	}
+	c.header = append(c.header, "Plural-Forms: "+rule.String())
+}
+
+// merge returns a new translation containing the messages of base, if not
+// nil, overridden by the ones of the catalog.
+func (c *Catalog) merge(base *translation, locale string) *translation {
+	var ret translation
+	ret.msgs = make(map[message][][]byte)
+	if base != nil {
+		ret = *base
+		ret.msgs = make(map[message][][]byte, len(base.msgs)+len(c.msgs))
+		for msg, translations := range base.msgs {
+			ret.msgs[msg] = translations
+		}
+	} else if builtin := builtinPluralForms(locale); len(builtin) > 0 {
+		ret.plural, _ = ParsePluralForms(builtin)
+		ret.cldr, ret.cldrIndexes = builtinCLDRPlurals(locale)
+	} else {
+		ret.plural = germanicPluralRule
+	}
+	for msg, translations := range c.msgs {
+		ret.msgs[msg] = translations
+	}
+	if len(c.header) > 0 {
+		ret.msgs[message{"", ""}] = [][]byte{
+			[]byte(strings.Join(c.header, "\n") + "\n")}
+	}
+	if c.rule != nil {
+		ret.plural = *c.rule
+		ret.cldr, ret.cldrIndexes = nil, nil
+		if checkPluralForms(locale, ret.plural) == nil {
+			ret.cldr, ret.cldrIndexes = builtinCLDRPlurals(locale)
+		}
+	}
+	return &ret
+}
+
+// Add installs the given catalog for the given domain and locale. If there is
+// a message catalog for them in LocaleDir, it is loaded first and the
+// messages of both are merged, with the ones of the given catalog taking
+// precedence. Likewise, catalogs added before are merged.
+//
+// Later changes of the catalog do not affect the installed messages.
+func (l *Locales) Add(domain, locale string, c *Catalog) error {
+	if c == nil {
+		return fmt.Errorf("Catalog must not be nil")
+	}
+	loaded, _ := l.get(domain, locale)
+	source := locale
+	locale = localeKey(locale)
+	l.mutex.Lock()
+	base, ok := l.translations[domain][locale]
+	if !ok {
+		base = loaded
+	}
+	if base != nil && base.pseudo {
+		l.mutex.Unlock()
+		return fmt.Errorf("Catalogs can not be added to the pseudo locale")
+	}
+	evicted := l.install(domain, locale, source, c.merge(base, locale), true)
+	logger := l.Logger
+	l.mutex.Unlock()
+	if logger != nil {
+		logEvictions(logger, evicted)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	locales := setupLocales(t)
+	catalog := NewCatalog()
+	catalog.Add("Message", "Overridden Message")
+	catalog.Add("New Message", "Translated New Message")
+	catalog.AddContext("Menu", "New Message", "Menu New Message")
+	catalog.AddContextPlural("Menu", "Item", "Items", "Menu Item",
+		"Menu Items", "Menu Second Items")
+	if err := locales.Add("test", "de", catalog); err != nil {
+		t.Fatalf("Could not add catalog: %v", err)
+	}
+	catalog.Add("Message", "Changed Message")
+	var second Catalog
+	second.AddPlural("File", "Files", "Datei", "Dateien")
+	if err := locales.Add("test", "de", &second); err != nil {
+		t.Fatalf("Could not add catalog: %v", err)
+	}
+	tr := locales.Translator("test", "de")
+	tests := []struct {
+		ret, translated string
+	}{
+		{tr.Singular("Message"), "Overridden Message"},
+		{tr.Singular("New Message"), "Translated New Message"},
+		{tr.Plural("Singular", "Plural", 3), "Translated Second Plural"},
+		{tr.ContextSingular("Menu", "Message"), "Menu Message"},
+		{tr.ContextSingular("Menu", "New Message"), "Menu New Message"},
+		{tr.ContextPlural("Menu", "Item", "Items", 3), "Menu Second Items"},
+		{tr.Plural("File", "Files", 5), "Dateien"},
+	}
+	for i, test := range tests {
+		if test.ret != test.translated {
+			t.Errorf("Test %v: Translation should be %q, got %q", i,
+				test.translated, test.ret)
+		}
+	}
+}
+
+func TestCatalogPluralRule(t *testing.T) {
+	var locales Locales
+	catalog := NewCatalog()
+	catalog.AddPlural("File", "Files", "Plik", "Pliki", "Plików")
+	if err := locales.Add("test", "pl", catalog); err != nil {
+		t.Fatalf("Could not add catalog: %v", err)
+	}
+	if ret := locales.Plural("test", "pl", "File", "Files", 5); ret != "Plików" {
+		t.Errorf(`Translation for 5 should be "Plików", got %q`, ret)
+	}
+	if err := catalog.SetHeader("Plural-Forms", "nplurals=2; plural=n > 1"); err != nil {
+		t.Fatalf("Could not set plural forms: %v", err)
+	}
+	if err := catalog.SetHeader("Plural-Forms", "nplurals=2; plural=n >"); err == nil {
+		t.Errorf("Setting invalid plural forms should fail")
+	}
+	catalog.SetHeader("Language", "pl")
+	if err := locales.Add("test", "xx", catalog); err != nil {
+		t.Fatalf("Could not add catalog: %v", err)
+	}
+	if ret := locales.Plural("test", "xx", "File", "Files", 1); ret != "Plik" {
+		t.Errorf(`Translation for 1 should be "Plik", got %q`, ret)
+	}
+	header := locales.Singular("test", "xx", "")
+	if header != "Plural-Forms: nplurals=2; plural=n > 1\nLanguage: pl\n" {
+		t.Errorf("Unexpected header: %q", header)
+	}
+	if err := locales.Add("test", PseudoLocale, catalog); err == nil {
+		t.Errorf("Adding catalogs to the pseudo locale should fail")
+	}
+}
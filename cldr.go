@@ -0,0 +1,469 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PluralCategory is a plural category as defined by the Unicode CLDR.
+type PluralCategory string
+
+// The plural categories of the Unicode CLDR.
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+// pluralOperands are the operands of a number as used by CLDR plural rules.
+type pluralOperands struct {
+	// N is the absolute value of the number.
+	N float64
+	// I is the integer digits of N.
+	I uint64
+	// V is the number of visible fraction digits, with trailing zeros.
+	V uint64
+	// W is the number of visible fraction digits, without trailing zeros.
+	W uint64
+	// F is the visible fraction digits, with trailing zeros.
+	F uint64
+	// T is the visible fraction digits, without trailing zeros.
+	T uint64
+}
+
+// get returns the operand of the given name.
+func (o *pluralOperands) get(operand byte) float64 {
+	switch operand {
+	case 'n':
+		return o.N
+	case 'i':
+		return float64(o.I)
+	case 'v':
+		return float64(o.V)
+	case 'w':
+		return float64(o.W)
+	case 'f':
+		return float64(o.F)
+	case 't':
+		return float64(o.T)
+	}
+	// Compact decimal exponents are not supported.
+	return 0
+}
+
+// parseOperands parses a decimal number like "-1.50" into its plural
+// operands.
+func parseOperands(number string) (pluralOperands, error) {
+	var ops pluralOperands
+	digits := strings.TrimPrefix(strings.TrimSpace(number), "-")
+	parts := strings.SplitN(digits, ".", 2)
+	if len(parts[0]) == 0 || strings.Trim(parts[0], "0123456789") != "" {
+		return ops, fmt.Errorf("Invalid number %q", number)
+	}
+	var err error
+	if ops.I, err = strconv.ParseUint(parts[0], 10, 64); err != nil {
+		return ops, fmt.Errorf("Invalid number %q: %v", number, err)
+	}
+	if len(parts) == 2 {
+		fraction := parts[1]
+		if len(fraction) == 0 || strings.Trim(fraction, "0123456789") != "" {
+			return ops, fmt.Errorf("Invalid number %q", number)
+		}
+		if ops.F, err = strconv.ParseUint(fraction, 10, 64); err != nil {
+			return ops, fmt.Errorf("Invalid number %q: %v", number, err)
+		}
+		ops.V = uint64(len(fraction))
+		trimmed := strings.TrimRight(fraction, "0")
+		ops.W = uint64(len(trimmed))
+		if len(trimmed) > 0 {
+			ops.T, _ = strconv.ParseUint(trimmed, 10, 64)
+		}
+	}
+	ops.N, err = strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return ops, fmt.Errorf("Invalid number %q: %v", number, err)
+	}
+	return ops, nil
+}
+
+// cldrCondition decides if a number's operands satisfy a plural rule.
+type cldrCondition func(ops *pluralOperands) bool
+
+// cldrRule is a plural rule for a single category.
+type cldrRule struct {
+	category  PluralCategory
+	condition cldrCondition
+}
+
+// CLDRPluralRules is a set of plural rules in the syntax of the Unicode CLDR,
+// e.g. "one: i = 1 and v = 0; other:".
+type CLDRPluralRules struct {
+	rules []cldrRule
+}
+
+// ParseCLDRPluralRules parses a set of plural rules in CLDR syntax. Rules are
+// separated by semicolons and consist of a category, a colon and a
+// condition. Samples introduced by "@integer" or "@decimal" are ignored. The
+// rule for the category "other" is optional and may have an empty condition.
+func ParseCLDRPluralRules(src string) (*CLDRPluralRules, error) {
+	var ret CLDRPluralRules
+	seen := make(map[PluralCategory]bool)
+	for _, rule := range strings.Split(src, ";") {
+		if len(strings.TrimSpace(rule)) == 0 {
+			continue
+		}
+		parts := strings.SplitN(rule, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Missing category in plural rule %q", rule)
+		}
+		category := PluralCategory(strings.TrimSpace(parts[0]))
+		switch category {
+		case PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther:
+		default:
+			return nil, fmt.Errorf("Unknown plural category %q", category)
+		}
+		if seen[category] {
+			return nil, fmt.Errorf("Duplicate plural category %q", category)
+		}
+		seen[category] = true
+		condition := parts[1]
+		if i := strings.Index(condition, "@"); i != -1 {
+			condition = condition[:i]
+		}
+		if category == PluralOther {
+			if len(strings.TrimSpace(condition)) != 0 {
+				return nil, fmt.Errorf(
+					"Plural category other must not have a condition")
+			}
+			continue
+		}
+		var parser cldrParser
+		cond, err := parser.Parse(condition)
+		if err != nil {
+			return nil, err
+		}
+		ret.rules = append(ret.rules, cldrRule{category, cond})
+	}
+	return &ret, nil
+}
+
+// Categories returns the categories of the rules in order, always ending
+// with PluralOther.
+func (r *CLDRPluralRules) Categories() []PluralCategory {
+	ret := make([]PluralCategory, 0, len(r.rules)+1)
+	for _, rule := range r.rules {
+		ret = append(ret, rule.category)
+	}
+	return append(ret, PluralOther)
+}
+
+// Select returns the plural category of the given decimal number, e.g. "1"
+// or "1.50". Visible fraction digits are significant.
+func (r *CLDRPluralRules) Select(number string) (PluralCategory, error) {
+	ops, err := parseOperands(number)
+	if err != nil {
+		return "", err
+	}
+	return r.selectOperands(&ops), nil
+}
+
+// selectOperands returns the plural category of the given operands.
+func (r *CLDRPluralRules) selectOperands(ops *pluralOperands) PluralCategory {
+	for _, rule := range r.rules {
+		if rule.condition(ops) {
+			return rule.category
+		}
+	}
+	return PluralOther
+}
+
+// PluralIndexes maps CLDR plural categories onto the plural form indexes of
+// gettext catalogs, e.g. {PluralOne: 0, PluralOther: 1} for English.
+type PluralIndexes map[PluralCategory]int
+
+// Index returns the plural form index of the given category. Categories
+// without an index are mapped to the index of PluralOther, or to zero if it
+// has none either.
+func (p PluralIndexes) Index(category PluralCategory) int {
+	if index, ok := p[category]; ok {
+		return index
+	}
+	return p[PluralOther]
+}
+
+// cldrPlurals holds the CLDR plural rules of a language and their mapping onto
+// the plural forms of the language's standard gettext plural forms.
+type cldrPlurals struct {
+	Rules   string
+	Indexes PluralIndexes
+}
+
+// cldrPluralTable maps languages to their CLDR plural rules. Decimal-only
+// categories are mapped to the gettext plural form used for fractions.
+var cldrPluralTable = map[string]cldrPlurals{
+	"ja":    {"", PluralIndexes{PluralOther: 0}},
+	"ko":    {"", PluralIndexes{PluralOther: 0}},
+	"zh":    {"", PluralIndexes{PluralOther: 0}},
+	"de":    {"one: i = 1 and v = 0", oneOther},
+	"en":    {"one: i = 1 and v = 0", oneOther},
+	"it":    {"one: i = 1 and v = 0", oneOther},
+	"nl":    {"one: i = 1 and v = 0", oneOther},
+	"sv":    {"one: i = 1 and v = 0", oneOther},
+	"es":    {"one: n = 1", oneOther},
+	"fr":    {"one: i = 0,1", oneOther},
+	"pt":    {"one: i = 0..1", oneOther},
+	"pt_PT": {"one: i = 1 and v = 0", oneOther},
+	"cs": {"one: i = 1 and v = 0; few: i = 2..4 and v = 0; many: v != 0",
+		czechIndexes},
+	"sk": {"one: i = 1 and v = 0; few: i = 2..4 and v = 0; many: v != 0",
+		czechIndexes},
+	"pl": {"one: i = 1 and v = 0; " +
+		"few: v = 0 and i % 10 = 2..4 and i % 100 != 12..14; " +
+		"many: v = 0 and i != 1 and i % 10 = 0..1 or " +
+		"v = 0 and i % 10 = 5..9 or v = 0 and i % 100 = 12..14",
+		slavicIndexes},
+	"ru": {"one: v = 0 and i % 10 = 1 and i % 100 != 11; " +
+		"few: v = 0 and i % 10 = 2..4 and i % 100 != 12..14; " +
+		"many: v = 0 and i % 10 = 0 or v = 0 and i % 10 = 5..9 or " +
+		"v = 0 and i % 100 = 11..14",
+		slavicIndexes},
+	"uk": {"one: v = 0 and i % 10 = 1 and i % 100 != 11; " +
+		"few: v = 0 and i % 10 = 2..4 and i % 100 != 12..14; " +
+		"many: v = 0 and i % 10 = 0 or v = 0 and i % 10 = 5..9 or " +
+		"v = 0 and i % 100 = 11..14",
+		slavicIndexes},
+	"ar": {"zero: n = 0; one: n = 1; two: n = 2; few: n % 100 = 3..10; " +
+		"many: n % 100 = 11..99",
+		PluralIndexes{PluralZero: 0, PluralOne: 1, PluralTwo: 2, PluralFew: 3,
+			PluralMany: 4, PluralOther: 5}},
+}
+
+// Mappings shared by several languages of cldrPluralTable.
+var (
+	oneOther     = PluralIndexes{PluralOne: 0, PluralOther: 1}
+	czechIndexes = PluralIndexes{PluralOne: 0, PluralFew: 1, PluralMany: 2,
+		PluralOther: 2}
+	slavicIndexes = PluralIndexes{PluralOne: 0, PluralFew: 1, PluralMany: 2,
+		PluralOther: 1}
+)
+
+// builtinCLDRPlurals returns the CLDR plural rules and their mapping onto
+// gettext plural forms for the given language or locale. It returns nil if
+// the language is unknown.
+func builtinCLDRPlurals(locale string) (*CLDRPluralRules, PluralIndexes) {
+	parsed, err := ParseLocale(locale)
+	if err != nil {
+		return nil, nil
+	}
+	plurals, ok := cldrPluralTable[parsed.Language]
+	if len(parsed.Territory) > 0 {
+		if specific, found := cldrPluralTable[parsed.Language+"_"+
+			parsed.Territory]; found {
+			plurals, ok = specific, true
+		}
+	}
+	if !ok {
+		return nil, nil
+	}
+	rules, err := ParseCLDRPluralRules(plurals.Rules)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid built-in plural rules for %q: %v", locale,
+			err))
+	}
+	return rules, plurals.Indexes
+}
+
+// cldrParser parses conditions of CLDR plural rules.
+type cldrParser struct {
+	// Remaining tokens
+	tokens []string
+}
+
+// A parser error
+type cldrError string
+
+func (c cldrError) Error() string {
+	return string(c)
+}
+
+// error emits a parser error.
+func (p *cldrParser) error(msg string, args ...interface{}) {
+	panic(cldrError(fmt.Sprintf(msg, args...) +
+		fmt.Sprintf(". Remaining: %q", strings.Join(p.tokens, " "))))
+}
+
+// tokenizeCLDR splits a condition into tokens.
+func tokenizeCLDR(condition string) []string {
+	var tokens []string
+	for i := 0; i < len(condition); {
+		c := condition[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c >= '0' && c <= '9' || c >= 'a' && c <= 'z':
+			j := i
+			for j < len(condition) && (condition[j] >= '0' &&
+				condition[j] <= '9' || condition[j] >= 'a' &&
+				condition[j] <= 'z') {
+				j++
+			}
+			tokens = append(tokens, condition[i:j])
+			i = j
+		case strings.HasPrefix(condition[i:], ".."),
+			strings.HasPrefix(condition[i:], "!="):
+			tokens = append(tokens, condition[i:i+2])
+			i += 2
+		default:
+			tokens = append(tokens, condition[i:i+1])
+			i++
+		}
+	}
+	return tokens
+}
+
+// accept consumes the next token iff it is the given one.
+func (p *cldrParser) accept(token string) bool {
+	if len(p.tokens) > 0 && p.tokens[0] == token {
+		p.tokens = p.tokens[1:]
+		return true
+	}
+	return false
+}
+
+// next consumes and returns the next token.
+func (p *cldrParser) next() string {
+	if len(p.tokens) == 0 {
+		p.error("Unexpected end of condition")
+	}
+	token := p.tokens[0]
+	p.tokens = p.tokens[1:]
+	return token
+}
+
+// Parse parses the given condition.
+func (p *cldrParser) Parse(condition string) (retCond cldrCondition,
+	retErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if err, ok := r.(cldrError); ok {
+				retCond = nil
+				retErr = err
+				return
+			}
+			panic(r)
+		}
+	}()
+	p.tokens = tokenizeCLDR(condition)
+	cond := p.pCondition()
+	if len(p.tokens) != 0 {
+		p.error("Trailing tokens")
+	}
+	return cond, nil
+}
+
+// pCondition parses a disjunction of and conditions.
+func (p *cldrParser) pCondition() cldrCondition {
+	cond := p.pAndCondition()
+	for p.accept("or") {
+		lhs, rhs := cond, p.pAndCondition()
+		cond = func(ops *pluralOperands) bool {
+			return lhs(ops) || rhs(ops)
+		}
+	}
+	return cond
+}
+
+// pAndCondition parses a conjunction of relations.
+func (p *cldrParser) pAndCondition() cldrCondition {
+	cond := p.pRelation()
+	for p.accept("and") {
+		lhs, rhs := cond, p.pRelation()
+		cond = func(ops *pluralOperands) bool {
+			return lhs(ops) && rhs(ops)
+		}
+	}
+	return cond
+}
+
+// pRelation parses a relation like "i % 10 = 2..4, 7".
+func (p *cldrParser) pRelation() cldrCondition {
+	operand := p.next()
+	if len(operand) != 1 || !strings.Contains("nivwftce", operand) {
+		p.error("Unknown operand %q", operand)
+	}
+	var mod float64
+	if p.accept("%") {
+		mod = p.pValue()
+		if mod == 0 {
+			p.error("Modulus must not be zero")
+		}
+	}
+	var negate bool
+	switch {
+	case p.accept("="):
+	case p.accept("!="):
+		negate = true
+	default:
+		p.error("Expected = or !=")
+	}
+	var ranges [][2]float64
+	for {
+		low := p.pValue()
+		high := low
+		if p.accept("..") {
+			high = p.pValue()
+		}
+		ranges = append(ranges, [2]float64{low, high})
+		if !p.accept(",") {
+			break
+		}
+	}
+	return func(ops *pluralOperands) bool {
+		value := ops.get(operand[0])
+		if mod != 0 {
+			value = math.Mod(value, mod)
+		}
+		// Ranges only contain integers.
+		match := false
+		if value == math.Trunc(value) {
+			for _, r := range ranges {
+				if value >= r[0] && value <= r[1] {
+					match = true
+					break
+				}
+			}
+		}
+		return match != negate
+	}
+}
+
+// pValue parses a non-negative integer.
+func (p *cldrParser) pValue() float64 {
+	token := p.next()
+	value, err := strconv.ParseUint(token, 10, 64)
+	if err != nil {
+		p.error("Could not parse number %q: %v", token, err)
+	}
+	return float64(value)
+}
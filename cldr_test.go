@@ -0,0 +1,133 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOperands(t *testing.T) {
+	tests := []struct {
+		number string
+		ops    pluralOperands
+	}{
+		{"1", pluralOperands{N: 1, I: 1}},
+		{"-1.50", pluralOperands{N: 1.5, I: 1, V: 2, W: 1, F: 50, T: 5}},
+		{"0.03", pluralOperands{N: 0.03, V: 2, W: 2, F: 3, T: 3}},
+		{"12.0", pluralOperands{N: 12, I: 12, V: 1}},
+	}
+	for _, test := range tests {
+		ops, err := parseOperands(test.number)
+		if err != nil || ops != test.ops {
+			t.Errorf("parseOperands(%q) should return %+v, got %+v (%v)",
+				test.number, test.ops, ops, err)
+		}
+	}
+	for _, number := range []string{"", "1.", ".5", "1e3", "x", "1.2.3"} {
+		if _, err := parseOperands(number); err == nil {
+			t.Errorf("parseOperands(%q) should fail", number)
+		}
+	}
+}
+
+func TestCLDRPluralRules(t *testing.T) {
+	tests := []struct {
+		rules      string
+		numbers    []string
+		categories []PluralCategory
+	}{
+		{"one: i = 1 and v = 0 @integer 1; other: @integer 0, 2~16",
+			[]string{"0", "1", "1.0", "2"},
+			[]PluralCategory{PluralOther, PluralOne, PluralOther, PluralOther}},
+		{"one: i = 0,1", []string{"0", "1.5", "2"},
+			[]PluralCategory{PluralOne, PluralOne, PluralOther}},
+		{"one: n = 1; few: n % 10 = 2..4, 7 and n % 100 != 12..14",
+			[]string{"1", "1.0", "2", "7", "12", "22", "22.5"},
+			[]PluralCategory{PluralOne, PluralOne, PluralFew, PluralFew,
+				PluralOther, PluralFew, PluralOther}},
+		{"one: v = 0 and i % 10 = 1 or f % 10 = 1",
+			[]string{"21", "0.1", "0.2"},
+			[]PluralCategory{PluralOne, PluralOne, PluralOther}},
+	}
+	for _, test := range tests {
+		rules, err := ParseCLDRPluralRules(test.rules)
+		if err != nil {
+			t.Errorf("Could not parse %q: %v", test.rules, err)
+			continue
+		}
+		for i, number := range test.numbers {
+			category, err := rules.Select(number)
+			if err != nil || category != test.categories[i] {
+				t.Errorf("%q: Category of %v should be %v, got %v (%v)",
+					test.rules, number, test.categories[i], category, err)
+			}
+		}
+	}
+	rules, _ := ParseCLDRPluralRules("few: n = 2; one: n = 1")
+	if cats := rules.Categories(); !reflect.DeepEqual(cats,
+		[]PluralCategory{PluralFew, PluralOne, PluralOther}) {
+		t.Errorf("Unexpected categories: %v", cats)
+	}
+	for _, src := range []string{"one", "once: n = 1", "one: n = 1; one: n = 2",
+		"one: x = 1", "one: n % 0 = 1", "one: n > 1", "one: n = 1 and",
+		"one: n = 1 2", "other: n = 1"} {
+		if _, err := ParseCLDRPluralRules(src); err == nil {
+			t.Errorf("ParseCLDRPluralRules(%q) should fail", src)
+		}
+	}
+}
+
+func TestBuiltinCLDRPlurals(t *testing.T) {
+	for language := range cldrPluralTable {
+		rules, indexes := builtinCLDRPlurals(language)
+		rule, err := ParsePluralForms(builtinPluralForms(language))
+		if err != nil {
+			t.Errorf("Could not parse plural forms of %q: %v", language, err)
+			continue
+		}
+		for _, category := range rules.Categories() {
+			if i := indexes.Index(category); i < 0 || i >= rule.NPlurals {
+				t.Errorf("Index of %v for %q is out of range: %v", category,
+					language, i)
+			}
+		}
+	}
+	tests := []struct {
+		locale, number string
+		index          int
+	}{
+		{"ru_RU", "21", 0},
+		{"ru_RU", "1.5", 1},
+		{"pl", "5", 2},
+		{"pt_PT", "0", 1},
+		{"pt_BR", "0", 0},
+		{"ja", "1", 0},
+	}
+	for _, test := range tests {
+		rules, indexes := builtinCLDRPlurals(test.locale)
+		category, err := rules.Select(test.number)
+		if err != nil || indexes.Index(category) != test.index {
+			t.Errorf("Index of %v for %q should be %v, got %v (%v)",
+				test.number, test.locale, test.index, indexes.Index(category),
+				err)
+		}
+	}
+	if rules, _ := builtinCLDRPlurals("xx"); rules != nil {
+		t.Errorf("There should be no rules for xx")
+	}
+}
@@ -0,0 +1,168 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// availableDirs returns the names of the directories in LocaleDir having a
+// message catalog for the given domain. It fails if LocaleDir can not be
+// read.
+func (l *Locales) availableDirs(domain string) ([]string, error) {
+	fsys, dir, _ := l.catalogFS()
+	// Glob ignores errors reading directories.
+	if _, err := fs.ReadDir(fsys, dir); err != nil {
+		return nil, err
+	}
+	paths, err := fs.Glob(fsys, catalogPath(escapeGlob(dir),
+		escapeGlob(domain), "*"))
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0, len(paths))
+	for _, name := range paths {
+		if info, err := fs.Stat(fsys, name); err != nil || info.IsDir() {
+			continue
+		}
+		dirs = append(dirs, path.Base(path.Dir(path.Dir(name))))
+	}
+	return dirs, nil
+}
+
+// AvailableLocales returns the sorted list of locales having a message catalog
+// for the given domain in LocaleDir, which must be readable. The locales are
+// normalized, see Locale, e.g. "pt_BR" for a directory named "pt-BR.UTF-8".
+func (l *Locales) AvailableLocales(domain string) ([]string, error) {
+	dirs, err := l.availableDirs(domain)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(dirs))
+	locales := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if locale := localeKey(dir); !found[locale] {
+			found[locale] = true
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+	return locales, nil
+}
+
+// AvailableDomains returns the sorted list of domains having a message catalog
+// for the given locale in LocaleDir. Directories are matched by their
+// normalized names, see Locale.
+func (l *Locales) AvailableDomains(locale string) ([]string, error) {
+	fsys, dir, _ := l.catalogFS()
+	paths, err := fs.Glob(fsys, catalogPath(escapeGlob(dir), "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	locale = localeKey(locale)
+	found := make(map[string]bool, len(paths))
+	domains := make([]string, 0, len(paths))
+	for _, name := range paths {
+		if localeKey(path.Base(path.Dir(path.Dir(name)))) != locale {
+			continue
+		}
+		if info, err := fs.Stat(fsys, name); err != nil || info.IsDir() {
+			continue
+		}
+		domain := strings.TrimSuffix(path.Base(name), ".mo")
+		if !found[domain] {
+			found[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+	return domains, nil
+}
+
+// escapeGlob escapes any characters having a special meaning in glob patterns.
+func escapeGlob(s string) string {
+	var ret strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`*?[]\`, c) {
+			ret.WriteRune('\\')
+		}
+		ret.WriteRune(c)
+	}
+	return ret.String()
+}
+
+// CatalogInfo describes a message catalog.
+type CatalogInfo struct {
+	Domain, Locale string
+	// Header contains the fields of the catalog's header entry, e.g.
+	// "Language" or "Plural-Forms".
+	Header map[string]string
+	// Entries is the number of messages in the catalog, not counting the
+	// header entry.
+	Entries int
+	// Translated is the number of messages having non-empty translations for
+	// all plural forms, see Statistics.Translated.
+	Translated int
+}
+
+// Language returns the language of the catalog as specified by its header,
+// falling back to the catalog's locale.
+func (c *CatalogInfo) Language() string {
+	if lang := c.Header["Language"]; len(lang) > 0 {
+		return lang
+	}
+	return c.Locale
+}
+
+// Completeness returns the fraction of translated messages in the catalog,
+// between 0 and 1.
+func (c *CatalogInfo) Completeness() float64 {
+	return fraction(c.Translated, c.Entries)
+}
+
+// CatalogInfo parses the message catalog for the given domain and locale and
+// returns information about it.
+func (l *Locales) CatalogInfo(domain, locale string) (*CatalogInfo, error) {
+	tr, _, err := l.load(domain, locale)
+	if err != nil {
+		return nil, err
+	}
+	stats := tr.statistics()
+	return &CatalogInfo{
+		Domain:     domain,
+		Locale:     locale,
+		Header:     parseHeader(tr.Singular("")),
+		Entries:    stats.Entries,
+		Translated: stats.Translated(),
+	}, nil
+}
+
+// parseHeader parses the fields of a catalog's header entry.
+func parseHeader(header string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(header, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return fields
+}
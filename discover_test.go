@@ -0,0 +1,76 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAvailable(t *testing.T) {
+	mo, err := os.ReadFile("test_locale/de/LC_MESSAGES/test.mo")
+	if err != nil {
+		t.Fatalf("Could not read message catalog: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"locale/de/LC_MESSAGES/test.mo":  {Data: mo},
+		"locale/de/LC_MESSAGES/other.mo": {Data: mo},
+		"locale/fr/LC_MESSAGES/test.mo":  {Data: mo},
+		"locale/it/LC_MESSAGES/other.po": {Data: mo},
+	}
+	tests := []struct {
+		l                *Locales
+		domains, locales []string
+	}{
+		{setupLocales(t), []string{"test"}, []string{"de"}},
+		{&Locales{FS: fsys, LocaleDir: "locale"}, []string{"other", "test"},
+			[]string{"de", "fr"}},
+	}
+	for i, test := range tests {
+		locales, err := test.l.AvailableLocales("test")
+		if err != nil || !reflect.DeepEqual(locales, test.locales) {
+			t.Errorf("Test %v: AvailableLocales should return %v, got %v (%v)",
+				i, test.locales, locales, err)
+		}
+		domains, err := test.l.AvailableDomains("de")
+		if err != nil || !reflect.DeepEqual(domains, test.domains) {
+			t.Errorf("Test %v: AvailableDomains should return %v, got %v (%v)",
+				i, test.domains, domains, err)
+		}
+	}
+	G, _, _, _ := (&Locales{FS: fsys, LocaleDir: "locale"}).Use("other", "de")
+	if ret := G("Message"); ret != "Translated Message" {
+		t.Errorf(`Translation of "Message" should be "Translated Message", got %q`,
+			ret)
+	}
+}
+
+func TestCatalogInfo(t *testing.T) {
+	info, err := setupLocales(t).CatalogInfo("test", "de")
+	if err != nil {
+		t.Fatalf("Could not get catalog info: %v", err)
+	}
+	if info.Language() != "de" || info.Entries != 4 || info.Translated != 4 ||
+		info.Completeness() != 1 {
+		t.Errorf("Unexpected catalog info: %+v", info)
+	}
+	if _, err := setupLocales(t).CatalogInfo("test", "fr"); err == nil {
+		t.Errorf("CatalogInfo should fail for missing catalogs")
+	}
+}
@@ -0,0 +1,119 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error is an error with a translatable message. Its Error method returns the
+// untranslated message, e.g. for logging, while Localize returns the
+// translated one, e.g. for showing it to users.
+type Error struct {
+	// Domain of the message. If empty, the domain of the translator is used.
+	Domain string
+	// Context of the message. May be empty.
+	Context string
+	// Singular is the message ID.
+	Singular string
+	// Plural is the plural message ID. If empty, the message has no plural
+	// forms.
+	Plural string
+	// N selects the plural form.
+	N int
+	// Args format the message like fmt.Sprintf if there are any.
+	Args []interface{}
+	// Err is the wrapped error. If set, its message is appended.
+	Err error
+}
+
+// NewError returns an Error for the given message, formatted with the given
+// arguments.
+func NewError(msg string, args ...interface{}) *Error {
+	return &Error{Singular: msg, Args: args}
+}
+
+// NewPluralError returns an Error for the given singular and plural message
+// and the number n, formatted with the given arguments.
+func NewPluralError(singular, plural string, n int,
+	args ...interface{}) *Error {
+	return &Error{Singular: singular, Plural: plural, N: n, Args: args}
+}
+
+// WrapError returns an Error for the given message, formatted with the given
+// arguments, wrapping err.
+func WrapError(err error, msg string, args ...interface{}) *Error {
+	return &Error{Singular: msg, Args: args, Err: err}
+}
+
+// Error returns the untranslated message.
+func (e *Error) Error() string {
+	return e.Localize(nil)
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Localize returns the message translated with the given translator. The
+// message of a wrapped error is localized, too, if it is an Error. Other
+// wrapped errors keep their untranslated message, including the messages of
+// any errors they wrap. A nil translator does not translate at all.
+func (e *Error) Localize(tr *Translator) string {
+	domain := e.Domain
+	if len(domain) == 0 {
+		domain = tr.Domain()
+	}
+	key := message{e.Singular, e.Plural}
+	if len(e.Context) > 0 {
+		key.Singular = e.Context + contextSeparator + e.Singular
+	}
+	t := tr.lookup(domain, key)
+	var ret string
+	switch {
+	case len(e.Plural) > 0 && len(e.Context) > 0:
+		ret = t.ContextPlural(e.Context, e.Singular, e.Plural, e.N)
+	case len(e.Plural) > 0:
+		ret = t.Plural(e.Singular, e.Plural, e.N)
+	case len(e.Context) > 0:
+		ret = t.ContextSingular(e.Context, e.Singular)
+	default:
+		ret = t.Singular(e.Singular)
+	}
+	if len(e.Args) > 0 {
+		ret = fmt.Sprintf(ret, e.Args...)
+	}
+	if inner, ok := e.Err.(*Error); ok {
+		ret += ": " + inner.Localize(tr)
+	} else if e.Err != nil {
+		ret += ": " + e.Err.Error()
+	}
+	return ret
+}
+
+// Localize returns the message of err translated with the given translator
+// if err is or wraps an Error, see errors.As. Otherwise, it returns the
+// untranslated message. Messages of errors wrapping the Error are lost.
+func Localize(err error, tr *Translator) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Localize(tr)
+	}
+	return err.Error()
+}
@@ -0,0 +1,75 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	locales := setupLocales(t)
+	catalog := NewCatalog()
+	catalog.Add("Could not save %q", "Konnte %q nicht speichern")
+	catalog.AddPlural("%d file is invalid", "%d files are invalid",
+		"%d Datei ist ungültig", "%d Dateien sind ungültig")
+	catalog.AddContext("Mail", "Could not send", "Konnte Mail nicht senden")
+	if err := locales.Add("test", "de", catalog); err != nil {
+		t.Fatalf("Could not add catalog: %v", err)
+	}
+	tr := locales.Translator("test", "de")
+	save := NewError("Could not save %q", "a.txt")
+	files := NewPluralError("%d file is invalid", "%d files are invalid", 2, 2)
+	send := &Error{Context: "Mail", Singular: "Could not send", Err: io.EOF}
+	wrapped := WrapError(files, "Could not save %q", "a.txt")
+	tests := []struct {
+		err                  error
+		untranslated, result string
+	}{
+		{save, `Could not save "a.txt"`, `Konnte "a.txt" nicht speichern`},
+		{files, "2 files are invalid", "2 Dateien sind ungültig"},
+		{send, "Could not send: EOF", "Konnte Mail nicht senden: EOF"},
+		{wrapped, `Could not save "a.txt": 2 files are invalid`,
+			`Konnte "a.txt" nicht speichern: 2 Dateien sind ungültig`},
+		{fmt.Errorf("Failed: %w", save), `Failed: Could not save "a.txt"`,
+			`Konnte "a.txt" nicht speichern`},
+		{WrapError(fmt.Errorf("reading config: %w", files), "Could not save %q",
+			"a.txt"), `Could not save "a.txt": reading config: 2 files are invalid`,
+			`Konnte "a.txt" nicht speichern: reading config: 2 files are invalid`},
+		{&Error{Domain: "other", Singular: "Message"}, "Message", "Message"},
+		{io.EOF, "EOF", "EOF"},
+	}
+	for i, test := range tests {
+		if ret := test.err.Error(); ret != test.untranslated {
+			t.Errorf("Test %v: Error should be %q, got %q", i,
+				test.untranslated, ret)
+		}
+		if ret := Localize(test.err, tr); ret != test.result {
+			t.Errorf("Test %v: Localized error should be %q, got %q", i,
+				test.result, ret)
+		}
+	}
+	var e *Error
+	if !errors.As(fmt.Errorf("Failed: %w", wrapped), &e) || e != wrapped {
+		t.Errorf("errors.As should find the wrapping error")
+	}
+	if !errors.Is(send, io.EOF) {
+		t.Errorf("errors.Is should find the wrapped error")
+	}
+}
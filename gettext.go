@@ -19,11 +19,17 @@ package gettext
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type message struct {
@@ -31,11 +37,23 @@ type message struct {
 }
 
 type translation struct {
-	msgs map[message][][]byte
-	pf   pluralForm
+	msgs   map[message][][]byte
+	plural PluralRule
+	// cldr are CLDR plural rules for decimal numbers, if known for the
+	// catalog's language.
+	cldr        *CLDRPluralRules
+	cldrIndexes PluralIndexes
+	// warnings contains non fatal problems found while parsing the catalog.
+	warnings []error
+	// pseudo is true iff messages are to be pseudo localized instead of
+	// translated.
+	pseudo bool
 }
 
 func (t *translation) Singular(msg string) string {
+	if t != nil && t.pseudo {
+		return Pseudolocalize(msg)
+	}
 	if t != nil {
 		if ret, ok := t.msgs[message{msg, ""}]; ok {
 			return string(ret[0])
@@ -45,14 +63,102 @@ func (t *translation) Singular(msg string) string {
 }
 
 func (t *translation) Plural(msg, plural string, n int) string {
+	return t.PluralUint64(msg, plural, uint64(n))
+}
+
+// PluralUint64 is like Plural but takes an unsigned number.
+func (t *translation) PluralUint64(msg, plural string, n uint64) string {
+	if t != nil && t.pseudo {
+		return Pseudolocalize([]string{msg, plural}[t.index(n, 2)])
+	}
 	if t != nil {
 		if ret, ok := t.msgs[message{msg, plural}]; ok {
-			return string(ret[t.pf(n)])
+			return string(ret[t.index(n, len(ret))])
+		}
+	}
+	if n == 1 {
+		return msg
+	}
+	return plural
+}
+
+// PluralDecimal is like Plural but takes a decimal number like "1.5". If the
+// number has fraction digits and there are CLDR plural rules for the
+// catalog's language, they are used to select the plural form. Otherwise, the
+// plural form for the integer digits is used.
+func (t *translation) PluralDecimal(msg, plural string,
+	number string) (string, error) {
+	ops, err := parseOperands(number)
+	if err != nil {
+		return "", err
+	}
+	if t != nil && t.pseudo {
+		if ops.I == 1 && ops.V == 0 {
+			return Pseudolocalize(msg), nil
+		}
+		return Pseudolocalize(plural), nil
+	}
+	if t != nil {
+		if ret, ok := t.msgs[message{msg, plural}]; ok {
+			var index uint64
+			if ops.V > 0 && t.cldr != nil {
+				category := t.cldr.selectOperands(&ops)
+				index = uint64(t.cldrIndexes.Index(category))
+				if index >= uint64(len(ret)) {
+					index = 0
+				}
+			} else {
+				index = t.index(ops.I, len(ret))
+			}
+			return string(ret[index]), nil
+		}
+	}
+	if ops.I == 1 && ops.V == 0 {
+		return msg, nil
+	}
+	return plural, nil
+}
+
+// index returns the index of the plural form for n. Like GNU gettext, it
+// falls back to the first form if the index is out of range.
+func (t *translation) index(n uint64, forms int) uint64 {
+	index := t.plural.pf(n)
+	if index >= uint64(forms) {
+		return 0
+	}
+	return index
+}
+
+// contextSeparator separates the message context from the message in message
+// catalogs.
+const contextSeparator = "\x04"
+
+func (t *translation) ContextSingular(context, msg string) string {
+	if t != nil && t.pseudo {
+		return Pseudolocalize(msg)
+	}
+	if t != nil {
+		key := message{context + contextSeparator + msg, ""}
+		if ret, ok := t.msgs[key]; ok {
+			return string(ret[0])
 		}
-		if n == 1 {
-			return msg
+	}
+	return msg
+}
+
+func (t *translation) ContextPlural(context, msg, plural string, n int) string {
+	if t != nil && t.pseudo {
+		return t.PluralUint64(msg, plural, uint64(n))
+	}
+	if t != nil {
+		key := message{context + contextSeparator + msg, plural}
+		if ret, ok := t.msgs[key]; ok {
+			return string(ret[t.index(uint64(n), len(ret))])
 		}
 	}
+	if n == 1 {
+		return msg
+	}
 	return plural
 }
 
@@ -62,8 +168,11 @@ func (p parseError) Error() string {
 	return string(p)
 }
 
-// parseMO parses GetText MO files
-func parseMO(dir, domain, locale string) (retTr *translation, retErr error) {
+// parseMO parses the GetText MO file at the given path of fsys. The locale is
+// used to pick standard plural forms if the catalog does not specify its
+// language.
+func parseMO(fsys fs.FS, name, locale string) (retTr *translation,
+	retErr error) {
 	defer func() {
 		if r := recover(); r != nil {
 			if e, ok := r.(parseError); ok {
@@ -74,15 +183,15 @@ func parseMO(dir, domain, locale string) (retTr *translation, retErr error) {
 			}
 		}
 	}()
-	path := filepath.Join(dir, locale, "LC_MESSAGES", domain+".mo")
 	error := func(msg string, args ...interface{}) {
 		panic(parseError(fmt.Sprintf(msg, args...)))
 	}
 
-	f, err := os.Open(path)
+	content, err := fs.ReadFile(fsys, name)
 	if err != nil {
-		error("Could not open message file: %v", err)
+		return nil, fmt.Errorf("Could not open message file: %w", err)
 	}
+	f := bytes.NewReader(content)
 
 	// Determine byte ordering
 	var magic [4]byte
@@ -109,8 +218,7 @@ func parseMO(dir, domain, locale string) (retTr *translation, retErr error) {
 	try(&major, "Could not parse major version")
 	try(&minor, "Could not parse minor version")
 	if major > 1 || minor > 1 {
-		error("Unknown file format: major %d, minor %d",
-			magic, major, minor)
+		error("Unknown file format: major %d, minor %d", major, minor)
 	}
 	var n, msgOff, transOff uint32
 	try(&n, "Could not parse number of strings")
@@ -174,41 +282,356 @@ func parseMO(dir, domain, locale string) (retTr *translation, retErr error) {
 	}
 
 	// Get plural forms function
-	meta := translation.Singular("")
-	begin := strings.Index(meta, "plural= ") + 8
-	if begin != 7 {
-		end := begin + strings.Index(meta[begin:], ";")
-		if end != -1 {
-			exp := meta[begin:end]
-			var parser peParser
-			translation.pf, err = parser.Parse([]byte(exp))
-			if err != nil {
-				return nil, err
-			}
-		}
+	header := parseHeader(translation.Singular(""))
+	language := header["Language"]
+	if len(language) == 0 {
+		language = locale
 	}
-	if translation.pf == nil {
-		translation.pf = func(n int) int {
-			if n == 1 {
-				return 0
-			}
-			return 1
+	if pluralForms, ok := header["Plural-Forms"]; ok {
+		translation.plural, err = ParsePluralForms(pluralForms)
+		if err != nil {
+			return nil, err
+		}
+		err = checkPluralForms(language, translation.plural)
+		if err != nil {
+			translation.warnings = append(translation.warnings, err)
+		} else {
+			translation.cldr, translation.cldrIndexes = builtinCLDRPlurals(
+				language)
 		}
+	} else if builtin := builtinPluralForms(language); len(builtin) > 0 {
+		translation.plural, err = ParsePluralForms(builtin)
+		if err != nil {
+			return nil, err
+		}
+		translation.cldr, translation.cldrIndexes = builtinCLDRPlurals(language)
+	} else {
+		translation.plural = germanicPluralRule
 	}
 	return &translation, nil
 }
 
 // Locales loads and keeps message catalogs and provides translation functions.
 // All methods belonging to Locales are thread safe.
+//
+// Locale identifiers are normalized, see Locale, so "de-DE" and "de_DE.UTF-8"
+// refer to the same message catalogs. Like GNU gettext, catalogs are searched
+// in the directory named by the given identifier first, then in the ones
+// returned by Locale.Dirs, e.g. in "de_DE" and then in "de". Directories
+// spelled differently, e.g. "de-DE", are found, too.
 type Locales struct {
 	translations map[string]map[string]*translation
+	// overlays maps layers to translations overriding the ones of
+	// translations.
+	overlays map[string]map[string]map[string]*translation
 	// LocaleDir is the directory to search for message catalogs.
 	LocaleDir string
+	// FS is the file system to search for message catalogs. If set, LocaleDir
+	// is a slash separated path within FS. Otherwise, the operating system's
+	// file system is used.
+	FS fs.FS
 	// Locale is the default locale to use.
 	Locale string
 	// Domain is the default domain to use.
 	Domain string
-	mutex  sync.RWMutex
+	// Fallbacks are the locales to consult in order for messages missing in
+	// the requested locale.
+	Fallbacks []string
+	// OnLoad is called after each attempt to load a message catalog, with a
+	// non-nil error if it failed.
+	OnLoad func(domain, locale string, err error)
+	// OnMissing is called for each lookup of a message translated neither in
+	// the requested locale nor in the fallbacks. The context is empty for
+	// messages without context.
+	OnMissing func(domain, locale, context, msgid string)
+	// Instrumentation receives lookup and load events if set.
+	Instrumentation Instrumentation
+	// Logger receives structured events about loaded, failed and evicted
+	// message catalogs, fallbacks and missing translations if set.
+	Logger *slog.Logger
+	// LogMissingEvery samples the debug events for missing translations:
+	// only every n-th one is logged. Zero or one logs all of them.
+	LogMissingEvery int
+	// MaxCatalogs is the maximum number of loaded message catalogs. If it is
+	// exceeded, the least recently used catalogs are evicted and reloaded
+	// transparently on their next use. Catalogs installed with Add are never
+	// evicted and do not count towards the limit. Zero means no limit.
+	MaxCatalogs int
+	// MaxBytes is like MaxCatalogs but limits the estimated memory used by
+	// the loaded message catalogs in bytes.
+	MaxBytes int64
+	mutex    sync.RWMutex
+	// loading tracks the message catalogs currently being loaded.
+	loading map[catalogKey]*loadCall
+	// cache tracks the usage of loaded message catalogs.
+	cache map[catalogKey]*cacheEntry
+	// failed holds the errors of message catalogs which could not be loaded,
+	// see Refresh.
+	failed map[catalogKey]error
+	// catalogs and bytes are the number and the estimated size of the loaded
+	// message catalogs which may be evicted.
+	catalogs int
+	bytes    int64
+	// missing counts missing translations for sampling their log events.
+	missing atomic.Uint64
+	// clock is incremented on each use of a catalog.
+	clock                  atomic.Uint64
+	hits, loads, evictions atomic.Uint64
+}
+
+// catalogFS returns the file system and the directory within to search for
+// message catalogs. If the file system is the one of the operating system,
+// root is the directory it is rooted at.
+func (l *Locales) catalogFS() (fsys fs.FS, dir, root string) {
+	l.mutex.RLock()
+	dir, fsys = l.LocaleDir, l.FS
+	l.mutex.RUnlock()
+	if len(dir) == 0 {
+		dir = "."
+	}
+	if fsys != nil {
+		return fsys, dir, ""
+	}
+	return os.DirFS(dir), ".", dir
+}
+
+// catalogPath returns the path of the message catalog for the given domain and
+// locale within dir.
+func catalogPath(dir, domain, locale string) string {
+	return path.Join(dir, locale, "LC_MESSAGES", domain+".mo")
+}
+
+// findCatalog calls open with the path of each candidate message catalog for
+// the given domain and locale, see catalogDirs, until it returns an error
+// other than fs.ErrNotExist. Directories named differently but having the
+// same normalized form are tried too. It returns the path of the catalog
+// found, or of the first candidate if there is none, for messages.
+func (l *Locales) findCatalog(domain, locale string,
+	open func(fsys fs.FS, name string) error) (string, error) {
+	fsys, dir, root := l.catalogFS()
+	try := func(name string) (bool, error) {
+		err := open(fsys, name)
+		return !errors.Is(err, fs.ErrNotExist), err
+	}
+	var available []string
+	scanned := false
+	for _, name := range catalogDirs(locale) {
+		candidate := catalogPath(dir, domain, name)
+		if found, err := try(candidate); found {
+			return displayPath(root, candidate), err
+		}
+		if !scanned {
+			available, _ = l.availableDirs(domain)
+			scanned = true
+		}
+		for _, other := range available {
+			if other == name || localeKey(other) != name {
+				continue
+			}
+			candidate = catalogPath(dir, domain, other)
+			if found, err := try(candidate); found {
+				return displayPath(root, candidate), err
+			}
+		}
+	}
+	name := catalogPath(dir, domain, locale)
+	return displayPath(root, name), fmt.Errorf("Could not open message file: %w",
+		&fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist})
+}
+
+// displayPath returns the path of the file with the given name in the
+// catalog file system as shown to users, see catalogFS.
+func displayPath(root, name string) string {
+	if len(root) > 0 {
+		return filepath.Join(root, filepath.FromSlash(name))
+	}
+	return name
+}
+
+// load parses the message catalog for the given domain and locale and
+// returns it along with its path, see findCatalog. For PseudoLocale, it
+// returns a pseudo translation and an empty path instead.
+func (l *Locales) load(domain, locale string) (*translation, string, error) {
+	if isPseudoLocale(locale) {
+		return newPseudoTranslation(), "", nil
+	}
+	var tr *translation
+	file, err := l.findCatalog(domain, locale, func(fsys fs.FS,
+		name string) (err error) {
+		tr, err = parseMO(fsys, name, localeKey(locale))
+		return err
+	})
+	return tr, file, err
+}
+
+// loadCall is an in-flight load of a message catalog.
+type loadCall struct {
+	// done is closed once the load has finished.
+	done chan struct{}
+	tr   *translation
+	path string
+	err  error
+}
+
+// get returns the translation for the given domain and locale, loading it if
+// it has not been loaded before. Loaded catalogs are looked up holding the
+// read lock only. Catalogs are parsed without holding the lock and concurrent
+// loads of the same catalog are deduplicated. It returns an error if the
+// message catalog can not be loaded, which is not tried again until Refresh
+// is called. The caller must not hold the lock.
+func (l *Locales) get(domain, source string) (*translation, error) {
+	locale := localeKey(source)
+	key := catalogKey{domain, locale}
+	l.mutex.RLock()
+	tr, ok := l.translations[domain][locale]
+	err := l.failed[key]
+	l.mutex.RUnlock()
+	if ok || err != nil {
+		return tr, err
+	}
+	l.mutex.Lock()
+	// The catalog may have been installed since releasing the read lock.
+	if tr, ok := l.translations[domain][locale]; ok {
+		l.mutex.Unlock()
+		return tr, nil
+	}
+	if err := l.failed[key]; err != nil {
+		l.mutex.Unlock()
+		return nil, err
+	}
+	if call, ok := l.loading[key]; ok {
+		l.mutex.Unlock()
+		<-call.done
+		return call.tr, call.err
+	}
+	if l.loading == nil {
+		l.loading = make(map[catalogKey]*loadCall)
+	}
+	call := &loadCall{done: make(chan struct{})}
+	l.loading[key] = call
+	reload := l.cache[key] != nil && l.cache[key].evicted
+	l.mutex.Unlock()
+
+	start := time.Now()
+	call.tr, call.path, call.err = l.load(domain, source)
+	duration := time.Since(start)
+
+	l.mutex.Lock()
+	delete(l.loading, key)
+	onLoad, inst, logger := l.OnLoad, l.Instrumentation, l.Logger
+	var evicted []eviction
+	if call.err == nil {
+		l.loads.Add(1)
+		if tr, ok := l.translations[domain][locale]; ok {
+			// A catalog has been added while loading.
+			call.tr = tr
+		} else {
+			evicted = l.install(domain, locale, source, call.tr, false)
+		}
+	} else {
+		l.fail(key, call.err)
+		if entry := l.cache[key]; entry != nil && entry.evicted {
+			delete(l.cache, key)
+		}
+	}
+	l.mutex.Unlock()
+	if inst != nil {
+		inst.Load(domain, locale, duration, call.err)
+	}
+	if logger != nil {
+		logLoad(logger, domain, locale, call.path, call.tr, duration, reload,
+			call.err)
+		logEvictions(logger, evicted)
+	}
+	if onLoad != nil {
+		onLoad(domain, locale, call.err)
+	}
+	close(call.done)
+	return call.tr, call.err
+}
+
+// has returns true if the translation contains the message with the given
+// key.
+func (t *translation) has(key message) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := t.msgs[key]
+	return ok || t.pseudo
+}
+
+// lookup returns the translation to use for the message with the given key:
+// the one of the requested locale or else of the first fallback containing
+// the message. If no one does, OnMissing is called and the translation of the
+// requested locale is returned.
+func (l *Locales) lookup(layers []string, domain, locale string,
+	key message) *translation {
+	locale = localeKey(locale)
+	l.mutex.RLock()
+	fallbacks, onMissing, inst := l.Fallbacks, l.OnMissing, l.Instrumentation
+	logger := l.Logger
+	l.mutex.RUnlock()
+	ret := l.lookupLocale(layers, domain, locale, key)
+	if ret.has(key) {
+		if inst != nil {
+			inst.Lookup(domain, locale, true)
+		}
+		return ret
+	}
+	for _, fallback := range fallbacks {
+		if tr := l.lookupLocale(layers, domain, fallback, key); tr.has(key) {
+			if inst != nil {
+				inst.Lookup(domain, locale, true)
+			}
+			if logger != nil {
+				logger.Debug("Using fallback translation", "domain", domain,
+					"locale", locale, "fallback", fallback, "msgid", key.Singular)
+			}
+			return tr
+		}
+	}
+	if inst != nil {
+		inst.Lookup(domain, locale, false)
+	}
+	if onMissing != nil || logger != nil {
+		context, msgid := "", key.Singular
+		if i := strings.Index(msgid, contextSeparator); i != -1 {
+			context, msgid = msgid[:i], msgid[i+len(contextSeparator):]
+		}
+		if onMissing != nil {
+			onMissing(domain, locale, context, msgid)
+		}
+		if logger != nil {
+			l.logMissing(logger, domain, locale, context, msgid)
+		}
+	}
+	return ret
+}
+
+// lookupLocale returns the translation of the given locale to use for the
+// message with the given key: the one of the first of the given overlay
+// layers containing the message, or else the one of the base catalog.
+// Translations are never modified once installed, so they may be used
+// without holding the lock. Evicted catalogs are reloaded.
+func (l *Locales) lookupLocale(layers []string, domain, locale string,
+	key message) *translation {
+	locale = localeKey(locale)
+	l.mutex.RLock()
+	for _, layer := range layers {
+		if tr := l.overlays[layer][domain][locale]; tr != nil {
+			if _, ok := tr.msgs[key]; ok {
+				l.mutex.RUnlock()
+				return tr
+			}
+		}
+	}
+	tr := l.translations[domain][locale]
+	evicted := l.touch(domain, locale)
+	l.mutex.RUnlock()
+	if evicted != nil {
+		tr, _ = l.get(domain, evicted.source)
+	}
+	return tr
 }
 
 // Singular returns the singular translation for the given domain, locale, and
@@ -216,9 +639,7 @@ type Locales struct {
 //
 // You have to load the corresponding message catalogs with Use before.
 func (l *Locales) Singular(domain, locale, msg string) string {
-	l.mutex.RLock()
-	defer l.mutex.RUnlock()
-	return l.translations[domain][locale].Singular(msg)
+	return l.lookup(nil, domain, locale, message{msg, ""}).Singular(msg)
 }
 
 // Plural returns the plural translation for the given domain, locale, both
@@ -227,9 +648,63 @@ func (l *Locales) Singular(domain, locale, msg string) string {
 // You have to load the corresponding message catalogs with Use before.
 func (l *Locales) Plural(domain, locale, singular, plural string,
 	n int) string {
+	return l.lookup(nil, domain, locale, message{singular, plural}).Plural(
+		singular, plural, n)
+}
+
+// Warnings returns non fatal problems found while loading the message catalog
+// for the given domain and locale, e.g. plural forms disagreeing with the
+// standard ones of the catalog's language.
+//
+// You have to load the corresponding message catalogs with Use before.
+func (l *Locales) Warnings(domain, locale string) []error {
 	l.mutex.RLock()
 	defer l.mutex.RUnlock()
-	return l.translations[domain][locale].Plural(singular, plural, n)
+	if tr := l.translations[domain][localeKey(locale)]; tr != nil {
+		return tr.warnings
+	}
+	return nil
+}
+
+// ContextSingular is like Singular but translates the message in the given
+// context.
+func (l *Locales) ContextSingular(domain, locale, context, msg string) string {
+	key := message{context + contextSeparator + msg, ""}
+	return l.lookup(nil, domain, locale, key).ContextSingular(context, msg)
+}
+
+// ContextPlural is like Plural but translates the messages in the given
+// context.
+func (l *Locales) ContextPlural(domain, locale, context, singular,
+	plural string, n int) string {
+	key := message{context + contextSeparator + singular, plural}
+	return l.lookup(nil, domain, locale, key).ContextPlural(context, singular,
+		plural, n)
+}
+
+// Plural64 is like Plural but takes an int64.
+func (l *Locales) Plural64(domain, locale, singular, plural string,
+	n int64) string {
+	return l.PluralUint64(domain, locale, singular, plural, uint64(n))
+}
+
+// PluralUint64 is like Plural but takes an uint64.
+func (l *Locales) PluralUint64(domain, locale, singular, plural string,
+	n uint64) string {
+	return l.lookup(nil, domain, locale, message{singular, plural}).PluralUint64(
+		singular, plural, n)
+}
+
+// PluralDecimal is like Plural but takes a decimal number like "1.50" or
+// "-3". Visible fraction digits are significant: If the number has any and
+// there are built-in CLDR plural rules for the catalog's language, those are
+// used to select the plural form. Otherwise, the integer digits are used.
+//
+// PluralDecimal returns an error if the number can not be parsed.
+func (l *Locales) PluralDecimal(domain, locale, singular, plural string,
+	number string) (string, error) {
+	return l.lookup(nil, domain, locale, message{singular, plural}).PluralDecimal(
+		singular, plural, number)
 }
 
 // Singular is a function returning a singular translation for the given
@@ -253,28 +728,16 @@ type DomainPlural func(domain string, singular string, plural string,
 // string.
 //
 // If the given domain and locale has not been loaded before, Use tries to
-// load the corresponding message catalog.
+// load the corresponding message catalog and the ones of the fallbacks.
 func (l *Locales) Use(domain, locale string) (Singular, Plural,
 	DomainSingular, DomainPlural) {
-	if len(domain) == 0 {
-		domain = l.Domain
-	}
-	if len(locale) == 0 {
-		locale = l.Locale
-	}
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
-	if l.translations == nil {
-		l.translations = make(map[string]map[string]*translation)
-	}
-	if _, ok := l.translations[domain]; !ok {
-		l.translations[domain] = make(map[string]*translation)
-	}
-	if _, ok := l.translations[domain][locale]; !ok {
-		ret, err := parseMO(l.LocaleDir, domain, locale)
-		if err == nil {
-			l.translations[domain][locale] = ret
-		}
+	domain, locale = l.defaults(domain, locale)
+	l.get(domain, locale)
+	l.mutex.RLock()
+	fallbacks := l.Fallbacks
+	l.mutex.RUnlock()
+	for _, fallback := range fallbacks {
+		l.get(domain, fallback)
 	}
 	singular := func(msg string) string {
 		return l.Singular(domain, locale, msg)
@@ -291,13 +754,32 @@ func (l *Locales) Use(domain, locale string) (Singular, Plural,
 	return singular, plural, dSingular, dPlural
 }
 
+// defaults returns the given domain and locale, or the default ones if they
+// are empty.
+func (l *Locales) defaults(domain, locale string) (string, string) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if len(domain) == 0 {
+		domain = l.Domain
+	}
+	if len(locale) == 0 {
+		locale = l.Locale
+	}
+	return domain, locale
+}
+
 // Use returns translation functions for the given locale dir, domain, and
 // locale. It sets the default locale and domain of DefaultLocales.
+//
+// Use is safe for concurrent use, but the defaults of DefaultLocales are
+// shared. Prefer creating own Locales with New.
 func Use(localedir, domain, locale string) (Singular, Plural, DomainSingular, DomainPlural) {
+	DefaultLocales.mutex.Lock()
 	DefaultLocales.LocaleDir = localedir
 	DefaultLocales.Locale = locale
 	DefaultLocales.Domain = domain
-	return DefaultLocales.Use("", "")
+	DefaultLocales.mutex.Unlock()
+	return DefaultLocales.Use(domain, locale)
 }
 
 var (
@@ -17,11 +17,53 @@
 package gettext
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
+// makeMO returns a little endian MO file containing the given messages. Keys
+// and values are given as stored in the file, i.e. with plural forms separated
+// by null bytes.
+func makeMO(msgs map[string]string) []byte {
+	keys := make([]string, 0, len(msgs))
+	for key := range msgs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var ids, strs bytes.Buffer
+	var idTable, strTable []uint32
+	for _, key := range keys {
+		idTable = append(idTable, uint32(len(key)), uint32(ids.Len()))
+		ids.WriteString(key + "\x00")
+		strTable = append(strTable, uint32(len(msgs[key])), uint32(strs.Len()))
+		strs.WriteString(msgs[key] + "\x00")
+	}
+	n := uint32(len(keys))
+	idStart := 28 + 16*n
+	strStart := idStart + uint32(ids.Len())
+	for i := 1; i < len(idTable); i += 2 {
+		idTable[i] += idStart
+		strTable[i] += strStart
+	}
+	var mo bytes.Buffer
+	binary.Write(&mo, binary.LittleEndian, []uint32{0x950412de, 0, n, 28,
+		28 + 8*n, 0, 0})
+	binary.Write(&mo, binary.LittleEndian, idTable)
+	binary.Write(&mo, binary.LittleEndian, strTable)
+	mo.Write(ids.Bytes())
+	mo.Write(strs.Bytes())
+	return mo.Bytes()
+}
+
 func setupLocales(t *testing.T) *Locales {
 	pwd, err := os.Getwd()
 	if err != nil {
@@ -84,3 +126,163 @@ func TestPlural(t *testing.T) {
 		}
 	}
 }
+
+func TestPluralQuantities(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ru/LC_MESSAGES/test.mo": {Data: makeMO(map[string]string{
+			"": "Language: ru\nPlural-Forms: nplurals=3; " +
+				"plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && " +
+				"(n%100<10 || n%100>=20) ? 1 : 2);\n",
+			"hour\x00hours": "час\x00часа\x00часов",
+		})},
+		"xx/LC_MESSAGES/test.mo": {Data: makeMO(map[string]string{
+			"":              "Plural-Forms: nplurals=2; plural=n;\n",
+			"hour\x00hours": "hour\x00hours",
+		})},
+	}
+	locales := Locales{FS: fsys}
+	tr := locales.Translator("test", "ru")
+	tests := []struct {
+		ret, translated string
+	}{
+		{tr.Plural64("hour", "hours", 21), "час"},
+		{tr.Plural64("hour", "hours", -2), "часов"},
+		{tr.PluralUint64("hour", "hours", 1000000000001), "час"},
+		{tr.PluralUint64("hour", "hours", 1<<63+14), "часа"},
+		{locales.Translator("test", "xx").Plural("hour", "hours", 5), "hour"},
+		{locales.Translator("test", "xx").Plural("hour", "hours", 1), "hours"},
+	}
+	for i, test := range tests {
+		if test.ret != test.translated {
+			t.Errorf("Test %v: Translation should be %q, got %q", i,
+				test.translated, test.ret)
+		}
+	}
+	decimals := []struct {
+		locale, number, translated string
+	}{
+		{"ru", "1", "час"},
+		{"ru", "5", "часов"},
+		{"ru", "1.5", "часа"},
+		{"ru", "21.0", "часа"},
+		{"xx", "1.5", "hours"},
+		{"de", "1.5", "hours"},
+		{"de", "1", "hour"},
+	}
+	for _, test := range decimals {
+		ret, err := locales.Translator("test", test.locale).PluralDecimal(
+			"hour", "hours", test.number)
+		if err != nil || ret != test.translated {
+			t.Errorf("Translation of %v hours in %v should be %q, got %q (%v)",
+				test.number, test.locale, test.translated, ret, err)
+		}
+	}
+	if _, err := tr.PluralDecimal("hour", "hours", "1,5"); err == nil {
+		t.Errorf("PluralDecimal should fail for invalid numbers")
+	}
+}
+
+func TestParseMODivisionByZero(t *testing.T) {
+	fsys := fstest.MapFS{
+		"de/LC_MESSAGES/test.mo": {Data: makeMO(map[string]string{
+			"":                   "Plural-Forms: nplurals=2; plural=n % (n > 1);\n",
+			"Message":            "Translated Message",
+			"Singular\x00Plural": "Translated Singular\x00Translated Plural",
+		})},
+	}
+	locales := Locales{FS: fsys}
+	if _, _, err := locales.load("test", "de"); err == nil {
+		t.Errorf("Loading a catalog dividing by zero should fail")
+	}
+	G, GN, _, _ := locales.Use("test", "de")
+	if ret := G("Message"); ret != "Message" {
+		t.Errorf(`Translation of "Message" should be "Message", got %q`, ret)
+	}
+	if ret := GN("Singular", "Plural", 0); ret != "Plural" {
+		t.Errorf(`Translation of "Singular", "Plural", 0 should be "Plural", got %q`,
+			ret)
+	}
+}
+
+// blockingFS is a file system whose Open blocks until release is closed and
+// which counts the opened files.
+type blockingFS struct {
+	files   fstest.MapFS
+	opening chan struct{}
+	release chan struct{}
+	opened  atomic.Int32
+}
+
+func (f *blockingFS) Open(name string) (fs.File, error) {
+	if f.opened.Add(1) == 1 {
+		close(f.opening)
+	}
+	<-f.release
+	return f.files.Open(name)
+}
+
+func TestUseLoadedReadLocked(t *testing.T) {
+	locales := setupLocales(t)
+	locales.Use("test", "de")
+	// Using a loaded catalog must not wait for readers.
+	locales.mutex.RLock()
+	defer locales.mutex.RUnlock()
+	done := make(chan struct{})
+	go func() {
+		locales.Use("test", "de")
+		locales.Translator("test", "de")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Using a loaded catalog blocked on the lock")
+	}
+}
+
+func TestConcurrentLoad(t *testing.T) {
+	locales := setupLocales(t)
+	locales.Use("test", "de")
+	slow := &blockingFS{
+		files: fstest.MapFS{"de/LC_MESSAGES/slow.mo": &fstest.MapFile{
+			Data: makeMO(map[string]string{"Message": "Slow Message"})}},
+		opening: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	locales.FS, locales.LocaleDir = slow, ""
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			singular, _, _, _ := locales.Use("slow", "de")
+			results[i] = singular("Message")
+		}(i)
+	}
+	<-slow.opening
+	// Lookups in loaded catalogs must not wait for the load.
+	done := make(chan string)
+	go func() {
+		done <- locales.Singular("test", "de", "Message")
+	}()
+	select {
+	case ret := <-done:
+		if ret != "Translated Message" {
+			t.Errorf(`Translation should be "Translated Message", got %q`, ret)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Lookup blocked by loading catalog")
+	}
+	close(slow.release)
+	wg.Wait()
+	for i, ret := range results {
+		if ret != "Slow Message" {
+			t.Errorf("Test %v: Translation should be %q, got %q", i,
+				"Slow Message", ret)
+		}
+	}
+	if opened := slow.opened.Load(); opened != 1 {
+		t.Errorf("Catalog should have been opened once, got %d", opened)
+	}
+}
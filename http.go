@@ -0,0 +1,234 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// acceptedLanguage is a language range of an Accept-Language header.
+type acceptedLanguage struct {
+	Tag string
+	Q   float64
+}
+
+// parseAcceptLanguage parses the given Accept-Language header and returns the
+// acceptable language ranges ordered by descending quality. Ranges with a
+// quality of zero and malformed ranges are skipped.
+func parseAcceptLanguage(header string) []acceptedLanguage {
+	var ret []acceptedLanguage
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		tag := strings.TrimSpace(params[0])
+		if len(tag) == 0 {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			var err error
+			q, err = strconv.ParseFloat(param[2:], 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+		}
+		if q > 0 {
+			ret = append(ret, acceptedLanguage{tag, q})
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Q > ret[j].Q
+	})
+	return ret
+}
+
+// matchLocale returns the available locale best matching the requested one,
+// or an empty string if there is none. Locales are compared in their
+// normalized form, see Locale. An exact match is preferred over a match of
+// the language only. PseudoLocale is always available.
+func matchLocale(requested string, available []string) string {
+	if isPseudoLocale(requested) {
+		return PseudoLocale
+	}
+	parsed, err := ParseLocale(requested)
+	if err != nil {
+		return ""
+	}
+	candidates := make([]Locale, len(available))
+	for i, locale := range available {
+		candidates[i], _ = ParseLocale(locale)
+	}
+	key := parsed.Dirs()[0]
+	for i, candidate := range candidates {
+		if len(candidate.Language) > 0 && candidate.Dirs()[0] == key {
+			return available[i]
+		}
+	}
+	for i, candidate := range candidates {
+		if candidate.Language == parsed.Language &&
+			candidate.Dirs()[0] == parsed.Language {
+			return available[i]
+		}
+	}
+	for i, candidate := range candidates {
+		if candidate.Language == parsed.Language {
+			return available[i]
+		}
+	}
+	return ""
+}
+
+// LocaleHandler is an http.Handler which selects a locale for each request
+// and stores a Translator for it in the request's context before calling the
+// wrapped handler. Use FromContext to retrieve the translator.
+//
+// The locale is the first available one of the following:
+//
+//  1. The value of the query parameter named QueryParam.
+//  2. The value of the cookie named CookieName.
+//  3. The best match for the request's Accept-Language header.
+//  4. The default locale of Locales.
+//
+// Only PseudoLocale and locales having a message catalog for the domain in
+// LocaleDir are considered available. They are determined on the first request for each
+// domain, call Refresh after installing new message catalogs. If they can not
+// be determined, e.g. because LocaleDir is missing, they are determined again
+// on the next request.
+type LocaleHandler struct {
+	// Locales to use. If nil, DefaultLocales will be used.
+	Locales *Locales
+	// Domain to use. If empty, the default domain of Locales will be used.
+	Domain string
+	// QueryParam is the name of a query parameter overriding the locale. No
+	// parameter is consulted if empty.
+	QueryParam string
+	// CookieName is the name of a cookie overriding the locale. No cookie is
+	// consulted if empty.
+	CookieName string
+	// Handler is the wrapped handler.
+	Handler http.Handler
+	// available caches the available locales by domain.
+	mutex     sync.RWMutex
+	available map[string][]string
+}
+
+// locales returns the locales to be used by the handler.
+func (h *LocaleHandler) locales() *Locales {
+	if h.Locales == nil {
+		return &DefaultLocales
+	}
+	return h.Locales
+}
+
+// availableLocales returns the available locales of the given domain,
+// determining them if they are not cached.
+func (h *LocaleHandler) availableLocales(locales *Locales,
+	domain string) []string {
+	h.mutex.RLock()
+	available, ok := h.available[domain]
+	h.mutex.RUnlock()
+	if ok {
+		return available
+	}
+	available, err := locales.AvailableLocales(domain)
+	if err != nil {
+		return nil
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.available == nil {
+		h.available = make(map[string][]string)
+	}
+	h.available[domain] = available
+	return available
+}
+
+// Refresh makes the handler determine the available locales again on the
+// next request. It also calls Refresh of Locales.
+func (h *LocaleHandler) Refresh() {
+	h.mutex.Lock()
+	h.available = nil
+	h.mutex.Unlock()
+	h.locales().Refresh()
+}
+
+// Negotiate returns the locale to use for the given request.
+func (h *LocaleHandler) Negotiate(r *http.Request) string {
+	locales := h.locales()
+	domain, defaultLocale := locales.defaults(h.Domain, "")
+	available := h.availableLocales(locales, domain)
+	if len(h.QueryParam) > 0 {
+		if ret := matchLocale(r.URL.Query().Get(h.QueryParam),
+			available); len(ret) > 0 {
+			return ret
+		}
+	}
+	if len(h.CookieName) > 0 {
+		if cookie, err := r.Cookie(h.CookieName); err == nil {
+			if ret := matchLocale(cookie.Value, available); len(ret) > 0 {
+				return ret
+			}
+		}
+	}
+	for _, lang := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if lang.Tag == "*" {
+			if ret := matchLocale(defaultLocale, available); len(ret) > 0 {
+				return ret
+			}
+			if len(available) > 0 {
+				return available[0]
+			}
+			continue
+		}
+		if ret := matchLocale(lang.Tag, available); len(ret) > 0 {
+			return ret
+		}
+	}
+	return defaultLocale
+}
+
+func (h *LocaleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept-Language")
+	if len(h.CookieName) > 0 {
+		w.Header().Add("Vary", "Cookie")
+	}
+	tr := h.locales().Translator(h.Domain, h.Negotiate(r))
+	h.Handler.ServeHTTP(w, r.WithContext(NewContext(r.Context(), tr)))
+}
+
+// RequestLocale returns the locale of the translator stored in ctx by
+// LocaleHandler, or an empty string if there is none.
+func RequestLocale(ctx context.Context) string {
+	return FromContext(ctx).Locale()
+}
+
+// RequestTranslations returns translation functions for the translator stored
+// in ctx by LocaleHandler. If there is none, it returns functions which do not
+// translate at all.
+func RequestTranslations(ctx context.Context) (Singular, Plural,
+	DomainSingular, DomainPlural) {
+	tr := FromContext(ctx)
+	return tr.Singular, tr.Plural, tr.DomainSingular, tr.DomainPlural
+}
@@ -0,0 +1,179 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		tags   []string
+	}{
+		{"", nil},
+		{"de", []string{"de"}},
+		{"en;q=0.5, de-DE, fr;q=0.8", []string{"de-DE", "fr", "en"}},
+		{"en;q=0, *;q=0.1, de", []string{"de", "*"}},
+		{"en;q=x, de", []string{"de"}},
+	}
+	for _, test := range tests {
+		var tags []string
+		for _, lang := range parseAcceptLanguage(test.header) {
+			tags = append(tags, lang.Tag)
+		}
+		if !reflect.DeepEqual(tags, test.tags) {
+			t.Errorf("parseAcceptLanguage(%q) should return %v, got %v",
+				test.header, test.tags, tags)
+		}
+	}
+}
+
+func TestMatchLocale(t *testing.T) {
+	available := []string{"de", "en_GB", "pt_BR.UTF-8"}
+	tests := []struct {
+		requested, locale string
+	}{
+		{"de", "de"},
+		{"de-AT", "de"},
+		{"EN-gb", "en_GB"},
+		{"en-US", "en_GB"},
+		{"pt_BR", "pt_BR.UTF-8"},
+		{"qps_PLOC", PseudoLocale},
+		{"fr", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if ret := matchLocale(test.requested, available); ret != test.locale {
+			t.Errorf("matchLocale(%q) should return %q, got %q",
+				test.requested, test.locale, ret)
+		}
+	}
+}
+
+func TestLocaleHandler(t *testing.T) {
+	locales := setupLocales(t)
+	locales.Locale = "en"
+	var locale, translated string
+	handler := LocaleHandler{
+		Locales:    locales,
+		Domain:     "test",
+		QueryParam: "lang",
+		CookieName: "lang",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter,
+			r *http.Request) {
+			locale = RequestLocale(r.Context())
+			G, _, _, _ := RequestTranslations(r.Context())
+			translated = G("Message")
+		}),
+	}
+	tests := []struct {
+		url, acceptLanguage, cookie string
+		locale, translated          string
+	}{
+		{"/", "", "", "en", "Message"},
+		{"/", "fr, de-DE;q=0.8", "", "de", "Translated Message"},
+		{"/", "fr, *;q=0.5", "", "de", "Translated Message"},
+		{"/", "fr", "de", "de", "Translated Message"},
+		{"/?lang=de", "fr", "", "de", "Translated Message"},
+		{"/?lang=fr", "de", "", "de", "Translated Message"},
+		{"/?lang=qps-ploc", "de", "", PseudoLocale,
+			Pseudolocalize("Message")},
+		{"/", "de", PseudoLocale, PseudoLocale, Pseudolocalize("Message")},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", test.url, nil)
+		r.Header.Set("Accept-Language", test.acceptLanguage)
+		if len(test.cookie) > 0 {
+			r.AddCookie(&http.Cookie{Name: "lang", Value: test.cookie})
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if locale != test.locale || translated != test.translated {
+			t.Errorf("%v %q %q: Locale and translation should be %q and %q, "+
+				"got %q and %q", test.url, test.acceptLanguage, test.cookie,
+				test.locale, test.translated, locale, translated)
+		}
+		vary := w.Header().Values("Vary")
+		if len(vary) != 2 || vary[0] != "Accept-Language" ||
+			vary[1] != "Cookie" {
+			t.Errorf("Vary should be Accept-Language and Cookie, got %v", vary)
+		}
+	}
+}
+
+func TestLocaleHandlerRefresh(t *testing.T) {
+	fsys := fstest.MapFS{
+		"en/LC_MESSAGES/app.mo": &fstest.MapFile{
+			Data: makeMO(map[string]string{})},
+	}
+	handler := LocaleHandler{
+		Locales: New(WithFS(fsys), WithLocale("en")),
+		Domain:  "app",
+		Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "de")
+	if locale := handler.Negotiate(r); locale != "en" {
+		t.Errorf(`Locale should be "en", got %q`, locale)
+	}
+	fsys["de/LC_MESSAGES/app.mo"] = &fstest.MapFile{
+		Data: makeMO(map[string]string{})}
+	if locale := handler.Negotiate(r); locale != "en" {
+		t.Errorf(`Cached locale should be "en", got %q`, locale)
+	}
+	handler.Refresh()
+	if locale := handler.Negotiate(r); locale != "de" {
+		t.Errorf(`Locale should be "de" after refresh, got %q`, locale)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if vary := w.Header().Values("Vary"); len(vary) != 1 {
+		t.Errorf("Vary should be Accept-Language only, got %v", vary)
+	}
+}
+
+func TestLocaleHandlerAvailable(t *testing.T) {
+	fsys := fstest.MapFS{}
+	locales := New(WithFS(fsys), WithLocaleDir("locale"), WithLocale("en"),
+		WithDomain("app"))
+	handler := LocaleHandler{
+		Locales: locales,
+		Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "de")
+	if locale := handler.Negotiate(r); locale != "en" {
+		t.Errorf(`Locale should be "en", got %q`, locale)
+	}
+	fsys["locale/de/LC_MESSAGES/app.mo"] = &fstest.MapFile{
+		Data: makeMO(map[string]string{})}
+	fsys["locale/fr/LC_MESSAGES/other.mo"] = &fstest.MapFile{
+		Data: makeMO(map[string]string{})}
+	if locale := handler.Negotiate(r); locale != "de" {
+		t.Errorf(`Locale should be "de" once LocaleDir exists, got %q`, locale)
+	}
+	locales.Domain = "other"
+	r.Header.Set("Accept-Language", "fr")
+	if locale := handler.Negotiate(r); locale != "fr" {
+		t.Errorf(`Locale should be "fr" for the other domain, got %q`, locale)
+	}
+}
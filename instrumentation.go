@@ -0,0 +1,102 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Instrumentation receives events of a Locales, e.g. to collect metrics. Its
+// methods are called concurrently and should return quickly.
+type Instrumentation interface {
+	// Lookup is called for each message lookup in the given domain and
+	// locale. Hit is false if the message has no translation.
+	Lookup(domain, locale string, hit bool)
+	// Load is called after each attempt to load the message catalog of the
+	// given domain and locale, with a non-nil error if it failed.
+	Load(domain, locale string, duration time.Duration, err error)
+}
+
+// ExpvarInstrumentation is an Instrumentation publishing counters via
+// expvar. The published map contains the following maps, each mapping
+// domains to maps of locales to counters:
+//
+//   - lookups: the number of lookups
+//   - misses: the number of lookups of messages without translation
+//   - loads: the number of loaded message catalogs
+//   - load_errors: the number of message catalogs failed to load
+//   - load_duration_ns: the total duration of all loads in nanoseconds
+type ExpvarInstrumentation struct {
+	lookups, misses, loads, loadErrors, loadDuration *expvar.Map
+	// mutex guards the creation of the maps of domains.
+	mutex sync.Mutex
+}
+
+// NewExpvarInstrumentation returns an ExpvarInstrumentation published with
+// the given name. Like expvar.Publish, it panics if the name is already
+// registered.
+func NewExpvarInstrumentation(name string) *ExpvarInstrumentation {
+	inst := &ExpvarInstrumentation{
+		lookups:      new(expvar.Map).Init(),
+		misses:       new(expvar.Map).Init(),
+		loads:        new(expvar.Map).Init(),
+		loadErrors:   new(expvar.Map).Init(),
+		loadDuration: new(expvar.Map).Init(),
+	}
+	m := expvar.NewMap(name)
+	m.Set("lookups", inst.lookups)
+	m.Set("misses", inst.misses)
+	m.Set("loads", inst.loads)
+	m.Set("load_errors", inst.loadErrors)
+	m.Set("load_duration_ns", inst.loadDuration)
+	return inst
+}
+
+// add adds delta to the counter of the given domain and locale in m.
+func (inst *ExpvarInstrumentation) add(m *expvar.Map, domain, locale string,
+	delta int64) {
+	domainMap, _ := m.Get(domain).(*expvar.Map)
+	if domainMap == nil {
+		inst.mutex.Lock()
+		domainMap, _ = m.Get(domain).(*expvar.Map)
+		if domainMap == nil {
+			domainMap = new(expvar.Map).Init()
+			m.Set(domain, domainMap)
+		}
+		inst.mutex.Unlock()
+	}
+	domainMap.Add(locale, delta)
+}
+
+func (inst *ExpvarInstrumentation) Lookup(domain, locale string, hit bool) {
+	inst.add(inst.lookups, domain, locale, 1)
+	if !hit {
+		inst.add(inst.misses, domain, locale, 1)
+	}
+}
+
+func (inst *ExpvarInstrumentation) Load(domain, locale string,
+	duration time.Duration, err error) {
+	if err != nil {
+		inst.add(inst.loadErrors, domain, locale, 1)
+		return
+	}
+	inst.add(inst.loads, domain, locale, 1)
+	inst.add(inst.loadDuration, domain, locale, int64(duration))
+}
@@ -0,0 +1,74 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"expvar"
+	"fmt"
+	"testing"
+)
+
+// expvarRuns makes the published names unique if tests are run repeatedly.
+var expvarRuns int
+
+func TestExpvarInstrumentation(t *testing.T) {
+	expvarRuns++
+	name := fmt.Sprintf("gettext_test_%d", expvarRuns)
+	inst := NewExpvarInstrumentation(name)
+	locales := setupLocales(t)
+	locales.Instrumentation = inst
+	locales.Use("test", "de")
+	locales.Use("test", "xx")
+	locales.Singular("test", "de", "Message")
+	locales.Singular("test", "de", "Unknown")
+	locales.ContextSingular("test", "de", "Menu", "Message")
+	locales.Singular("test", "xx", "Message")
+	published, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		t.Fatalf("Instrumentation should be published")
+	}
+	tests := []struct {
+		name, domain, locale string
+		value                int64
+	}{
+		{"lookups", "test", "de", 3},
+		{"misses", "test", "de", 1},
+		{"lookups", "test", "xx", 1},
+		{"misses", "test", "xx", 1},
+		{"loads", "test", "de", 1},
+		{"load_errors", "test", "xx", 1},
+	}
+	for i, test := range tests {
+		var value int64
+		if domains, ok := published.Get(test.name).(*expvar.Map); ok {
+			if locales, ok := domains.Get(test.domain).(*expvar.Map); ok {
+				if counter, ok := locales.Get(test.locale).(*expvar.Int); ok {
+					value = counter.Value()
+				}
+			}
+		}
+		if value != test.value {
+			t.Errorf("Test %v: %v for %v/%v should be %v, got %v", i,
+				test.name, test.domain, test.locale, test.value, value)
+		}
+	}
+	durations := published.Get("load_duration_ns").(*expvar.Map)
+	duration := durations.Get("test").(*expvar.Map).Get("de").(*expvar.Int)
+	if duration.Value() <= 0 {
+		t.Errorf("Load duration should be positive, got %v", duration.Value())
+	}
+}
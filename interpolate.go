@@ -0,0 +1,286 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// placeholderError is returned for malformed or unresolvable placeholders.
+type placeholderError struct {
+	err    string
+	format string
+}
+
+func (p placeholderError) Error() string {
+	return fmt.Sprintf("%v in %q", p.err, p.format)
+}
+
+// placeholderChar returns true iff c may be used in a placeholder name.
+func placeholderChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z'
+}
+
+// scanPlaceholders walks through format, calling text for each literal part
+// and name for each placeholder.
+func scanPlaceholders(format string, text func(string), name func(string)) error {
+	for i := 0; i < len(format); {
+		switch format[i] {
+		case '{':
+			if strings.HasPrefix(format[i:], "{{") {
+				text("{")
+				i += 2
+				continue
+			}
+			end := strings.IndexByte(format[i:], '}')
+			if end == -1 {
+				return placeholderError{"Unclosed placeholder", format}
+			}
+			placeholder := format[i+1 : i+end]
+			if len(placeholder) == 0 {
+				return placeholderError{"Empty placeholder", format}
+			}
+			for j := 0; j < len(placeholder); j++ {
+				if !placeholderChar(placeholder[j]) {
+					return placeholderError{fmt.Sprintf(
+						"Invalid placeholder name %q", placeholder), format}
+				}
+			}
+			name(placeholder)
+			i += end + 1
+		case '}':
+			if !strings.HasPrefix(format[i:], "}}") {
+				return placeholderError{"Unmatched closing brace", format}
+			}
+			text("}")
+			i += 2
+		default:
+			end := strings.IndexAny(format[i:], "{}")
+			if end == -1 {
+				end = len(format) - i
+			}
+			text(format[i : i+end])
+			i += end
+		}
+	}
+	return nil
+}
+
+// Placeholders returns the names of all placeholders in the given format
+// string in order of appearance, without duplicates.
+func Placeholders(format string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	err := scanPlaceholders(format, func(string) {}, func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// lookupArg returns the value for the named placeholder. args may be a map
+// with string keys or a struct or a pointer to a struct. Struct fields are
+// matched by their name or by a `gettext:"name"` tag.
+func lookupArg(args interface{}, name string) (interface{}, bool) {
+	v := reflect.ValueOf(args)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		ret := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !ret.IsValid() {
+			return nil, false
+		}
+		return ret.Interface(), true
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			if tag := field.Tag.Get("gettext"); tag == name ||
+				tag == "" && field.Name == name {
+				return v.Field(i).Interface(), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Interpolate replaces the named placeholders in the given format string with
+// the corresponding values of args, formatted like fmt.Sprint does.
+//
+// A placeholder consists of a name made of letters, digits and underscores
+// enclosed in braces, e.g. "{count}". Literal braces are written as "{{" and
+// "}}". args may be a map with string keys, a struct, or a pointer to a
+// struct. Struct fields are matched by name unless they have a
+// `gettext:"name"` tag.
+//
+// If a placeholder can not be resolved, it is left untouched and an error is
+// returned along with the interpolated string.
+func Interpolate(format string, args interface{}) (string, error) {
+	var buffer bytes.Buffer
+	var missing []string
+	err := scanPlaceholders(format, func(text string) {
+		buffer.WriteString(text)
+	}, func(name string) {
+		if value, ok := lookupArg(args, name); ok {
+			fmt.Fprint(&buffer, value)
+		} else {
+			missing = append(missing, name)
+			buffer.WriteString("{" + name + "}")
+		}
+	})
+	if err != nil {
+		return format, err
+	}
+	if len(missing) > 0 {
+		return buffer.String(), placeholderError{fmt.Sprintf(
+			"Missing arguments for %v", strings.Join(missing, ", ")), format}
+	}
+	return buffer.String(), nil
+}
+
+// CheckPlaceholders checks that msgid and msgstr use the same set of
+// placeholders. The order of the placeholders may differ.
+func CheckPlaceholders(msgid, msgstr string) error {
+	want, err := Placeholders(msgid)
+	if err != nil {
+		return err
+	}
+	return checkPlaceholders(want, msgstr, false)
+}
+
+// checkPlaceholders checks msgstr against the wanted placeholders. If subset
+// is true, msgstr may omit some of the placeholders.
+func checkPlaceholders(want []string, msgstr string, subset bool) error {
+	got, err := Placeholders(msgstr)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[string]bool)
+	for _, name := range want {
+		wanted[name] = true
+	}
+	var unknown, missing []string
+	for _, name := range got {
+		if !wanted[name] {
+			unknown = append(unknown, name)
+		}
+		delete(wanted, name)
+	}
+	if !subset {
+		for name := range wanted {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+	}
+	switch {
+	case len(unknown) > 0:
+		return placeholderError{fmt.Sprintf("Unknown placeholders %v",
+			strings.Join(unknown, ", ")), msgstr}
+	case len(missing) > 0:
+		return placeholderError{fmt.Sprintf("Missing placeholders %v",
+			strings.Join(missing, ", ")), msgstr}
+	}
+	return nil
+}
+
+// CheckPlaceholders checks the placeholders of all messages in the translation
+// and returns an error for each mismatch.
+//
+// Translations of plural messages may omit placeholders, as languages often
+// do not mention the number in the singular form.
+func (t *translation) CheckPlaceholders() []error {
+	if t == nil {
+		return nil
+	}
+	var errs []error
+	for msg, translations := range t.msgs {
+		if len(msg.Singular) == 0 {
+			continue
+		}
+		if len(msg.Plural) == 0 {
+			if err := CheckPlaceholders(msg.Singular,
+				string(translations[0])); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		singular, err := Placeholders(msg.Singular)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		plural, err := Placeholders(msg.Plural)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, tr := range translations {
+			if err := checkPlaceholders(append(singular, plural...),
+				string(tr), true); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// CheckPlaceholders checks the placeholders of all messages in the catalog for
+// the given domain and locale. See CheckPlaceholders for details.
+//
+// You have to load the corresponding message catalogs with Use before.
+func (l *Locales) CheckPlaceholders(domain, locale string) []error {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.translations[domain][localeKey(locale)].CheckPlaceholders()
+}
+
+// NamedSingular is like Singular but replaces named placeholders in the
+// translation with the values of args. See Interpolate for details.
+func (l *Locales) NamedSingular(domain, locale, msg string,
+	args interface{}) string {
+	ret, _ := Interpolate(l.Singular(domain, locale, msg), args)
+	return ret
+}
+
+// NamedPlural is like Plural but replaces named placeholders in the
+// translation with the values of args. See Interpolate for details.
+func (l *Locales) NamedPlural(domain, locale, singular, plural string, n int,
+	args interface{}) string {
+	ret, _ := Interpolate(l.Plural(domain, locale, singular, plural, n), args)
+	return ret
+}
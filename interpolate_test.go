@@ -0,0 +1,87 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	type args struct {
+		Name  string
+		Count int `gettext:"count"`
+	}
+	tests := []struct {
+		format string
+		args   interface{}
+		ret    string
+		err    bool
+	}{
+		{"Hello {name}", map[string]string{"name": "World"}, "Hello World", false},
+		{"{a}{b}{a}", map[string]interface{}{"a": 1, "b": "x"}, "1x1", false},
+		{"Hi {Name}, {count} new", args{"Ada", 3}, "Hi Ada, 3 new", false},
+		{"Hi {Name}, {count} new", &args{"Ada", 3}, "Hi Ada, 3 new", false},
+		{"{{literal}} {x}", map[string]int{"x": 1}, "{literal} 1", false},
+		{"Hello {name}", nil, "Hello {name}", true},
+		{"Hello {Count}", args{}, "Hello {Count}", true},
+		{"Hello {name", nil, "Hello {name", true},
+		{"Hello }", nil, "Hello }", true},
+		{"Hello {}", nil, "Hello {}", true},
+		{"Hello {a b}", nil, "Hello {a b}", true},
+	}
+	for _, test := range tests {
+		ret, err := Interpolate(test.format, test.args)
+		if ret != test.ret || (err != nil) != test.err {
+			t.Errorf("Interpolate(%q, %v) should return %q (error: %v), got %q (%v)",
+				test.format, test.args, test.ret, test.err, ret, err)
+		}
+	}
+}
+
+func TestCheckPlaceholders(t *testing.T) {
+	tests := []struct {
+		msgid, msgstr string
+		ok            bool
+	}{
+		{"Hello {name}", "Hallo {name}", true},
+		{"{a} and {b}", "{b} und {a}", true},
+		{"{{a}} and {b}", "{{b}} und {b}", true},
+		{"Hello {name}", "Hallo", false},
+		{"Hello {name}", "Hallo {nam}", false},
+		{"Hello {name}", "Hallo {name", false},
+	}
+	for _, test := range tests {
+		err := CheckPlaceholders(test.msgid, test.msgstr)
+		if (err == nil) != test.ok {
+			t.Errorf("CheckPlaceholders(%q, %q) should succeed: %v, got %v",
+				test.msgid, test.msgstr, test.ok, err)
+		}
+	}
+}
+
+func TestNamedSingular(t *testing.T) {
+	locales := setupLocales(t)
+	locales.Use("test", "de")
+	ret := locales.NamedSingular("test", "de", "Hello {name}",
+		map[string]string{"name": "World"})
+	if ret != "Hello World" {
+		t.Errorf(`NamedSingular should return "Hello World", got %q`, ret)
+	}
+	if errs := locales.CheckPlaceholders("test", "de"); len(errs) > 0 {
+		t.Errorf("CheckPlaceholders should not fail, got %v", errs)
+	}
+}
@@ -0,0 +1,100 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"sync/atomic"
+)
+
+// LazyMessage is a message which is translated when it is rendered rather
+// than when it is defined, e.g. for package level variables initialized
+// before the locale is known. Create it with N_ or NC_ and let xgettext
+// extract it with the following options:
+//
+//	--keyword=N_ --keyword=NC_:1c,2
+type LazyMessage struct {
+	// Domain of the message. If empty, the domain of the translator is used.
+	Domain string
+	// Context of the message. May be empty.
+	Context string
+	// Msgid is the untranslated message.
+	Msgid string
+}
+
+// N_ marks the given message for translation and returns it as a
+// LazyMessage.
+func N_(msgid string) LazyMessage {
+	return LazyMessage{Msgid: msgid}
+}
+
+// NC_ is like N_ but for a message in the given context.
+func NC_(context, msgid string) LazyMessage {
+	return LazyMessage{Context: context, Msgid: msgid}
+}
+
+// InDomain returns a copy of the message in the given domain.
+func (m LazyMessage) InDomain(domain string) LazyMessage {
+	m.Domain = domain
+	return m
+}
+
+// Translate returns the message translated with the given translator. A nil
+// translator does not translate at all.
+func (m LazyMessage) Translate(tr *Translator) string {
+	domain := m.Domain
+	if len(domain) == 0 {
+		domain = tr.Domain()
+	}
+	if len(m.Context) > 0 {
+		key := message{m.Context + contextSeparator + m.Msgid, ""}
+		return tr.lookup(domain, key).ContextSingular(m.Context, m.Msgid)
+	}
+	return tr.lookup(domain, message{m.Msgid, ""}).Singular(m.Msgid)
+}
+
+// In returns the message translated in the given locale using the given
+// locales. The default domain of the locales is used unless the message has
+// a domain.
+func (m LazyMessage) In(l *Locales, locale string) string {
+	return m.Translate(l.Translator(m.Domain, locale))
+}
+
+// String returns the message translated with DefaultTranslator.
+func (m LazyMessage) String() string {
+	return m.Translate(DefaultTranslator())
+}
+
+// defaultTranslator is the translator set by SetDefaultTranslator.
+var defaultTranslator atomic.Pointer[Translator]
+
+// SetDefaultTranslator sets the translator returned by DefaultTranslator. It
+// is safe for concurrent use.
+func SetDefaultTranslator(tr *Translator) {
+	defaultTranslator.Store(tr)
+}
+
+// DefaultTranslator returns the translator set by SetDefaultTranslator or, if
+// there is none, a translator for the default domain and locale of
+// DefaultLocales.
+func DefaultTranslator() *Translator {
+	if tr := defaultTranslator.Load(); tr != nil {
+		return tr
+	}
+	domain, locale := DefaultLocales.defaults("", "")
+	return &Translator{locales: &DefaultLocales, domain: domain,
+		locale: locale, key: localeKey(locale)}
+}
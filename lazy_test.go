@@ -0,0 +1,58 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"fmt"
+	"testing"
+)
+
+var (
+	lazyMessage     = N_("Message")
+	lazyMenuMessage = NC_("Menu", "Message")
+)
+
+func TestLazyMessage(t *testing.T) {
+	locales := setupLocales(t)
+	locales.Domain = "test"
+	tr := locales.Translator("test", "de")
+	tests := []struct {
+		ret, translated string
+	}{
+		{lazyMessage.Translate(tr), "Translated Message"},
+		{lazyMenuMessage.Translate(tr), "Menu Message"},
+		{lazyMessage.Translate(nil), "Message"},
+		{lazyMessage.InDomain("other").Translate(tr), "Message"},
+		{lazyMessage.In(locales, "de"), "Translated Message"},
+		{lazyMessage.InDomain("test").In(locales, "fr"), "Message"},
+		{lazyMessage.String(), "Message"},
+	}
+	for i, test := range tests {
+		if test.ret != test.translated {
+			t.Errorf("Test %v: Translation should be %q, got %q", i,
+				test.translated, test.ret)
+		}
+	}
+	SetDefaultTranslator(tr)
+	defer SetDefaultTranslator(nil)
+	if ret := fmt.Sprint(lazyMenuMessage); ret != "Menu Message" {
+		t.Errorf(`Translation should be "Menu Message", got %q`, ret)
+	}
+	if DefaultTranslator() != tr {
+		t.Errorf("DefaultTranslator should return the set translator")
+	}
+}
@@ -0,0 +1,274 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Locale is a parsed locale identifier, either a POSIX locale like
+// "de_DE.UTF-8@euro" or a BCP 47 language tag like "sr-Latn-RS".
+//
+// Locales normalizes all locale identifiers it is given, so "de-DE",
+// "de_DE.utf8" and "DE_de" refer to the same message catalogs.
+type Locale struct {
+	// Language is the lower case ISO 639 language code.
+	Language string
+	// Script is the title case ISO 15924 script code of a BCP 47 tag.
+	Script string
+	// Territory is the upper case ISO 3166 country or UN M.49 area code.
+	Territory string
+	// Codeset is the lower case codeset without punctuation, e.g. "utf8".
+	Codeset string
+	// Modifier is the lower case POSIX modifier or BCP 47 variant.
+	Modifier string
+}
+
+// scriptModifiers maps scripts to the POSIX modifiers commonly used for them.
+var scriptModifiers = map[string]string{
+	"Cyrl": "cyrillic",
+	"Deva": "devanagari",
+	"Latn": "latin",
+}
+
+// ParseLocale parses the given POSIX locale or BCP 47 language tag.
+// Extensions and private use subtags of language tags are ignored.
+func ParseLocale(s string) (Locale, error) {
+	var ret Locale
+	rest := s
+	if i := strings.IndexByte(rest, '@'); i != -1 {
+		rest, ret.Modifier = rest[:i], strings.ToLower(rest[i+1:])
+	}
+	if i := strings.IndexByte(rest, '.'); i != -1 {
+		rest, ret.Codeset = rest[:i], normalizeCodeset(rest[i+1:])
+	}
+	parts := strings.FieldsFunc(rest, func(r rune) bool {
+		return r == '_' || r == '-'
+	})
+	if len(parts) == 0 || !isAlpha(parts[0]) || len(parts[0]) < 2 ||
+		len(parts[0]) > 8 || len(parts[0]) == 4 {
+		return Locale{}, fmt.Errorf("Invalid language in locale %q", s)
+	}
+	ret.Language = strings.ToLower(parts[0])
+	for _, part := range parts[1:] {
+		switch {
+		case len(part) == 1:
+			// Extensions and private use subtags
+			return ret, nil
+		case len(part) == 4 && isAlpha(part) && len(ret.Script) == 0 &&
+			len(ret.Territory) == 0:
+			ret.Script = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		case (len(part) == 2 && isAlpha(part) || len(part) == 3 &&
+			isDigit(part)) && len(ret.Territory) == 0:
+			ret.Territory = strings.ToUpper(part)
+		case (len(part) >= 5 && len(part) <= 8 || len(part) == 4 &&
+			isDigit(part[:1])) && isAlnum(part):
+			if len(ret.Modifier) == 0 {
+				ret.Modifier = strings.ToLower(part)
+			}
+		default:
+			return Locale{}, fmt.Errorf("Invalid subtag %q in locale %q",
+				part, s)
+		}
+	}
+	return ret, nil
+}
+
+// normalizeCodeset returns the lower case codeset without punctuation like
+// GNU libc does, e.g. "utf8" for "UTF-8".
+func normalizeCodeset(codeset string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		}
+		return -1
+	}, codeset)
+}
+
+// isAlpha returns true if s consists of ASCII letters only.
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigit returns true if s consists of ASCII digits only.
+func isDigit(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isAlnum returns true if s consists of ASCII letters and digits only.
+func isAlnum(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' &&
+			r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// modifier returns the modifier of the locale, or the one commonly used for
+// its script if there is none.
+func (l Locale) modifier() string {
+	if len(l.Modifier) > 0 || len(l.Script) == 0 {
+		return l.Modifier
+	}
+	if modifier, ok := scriptModifiers[l.Script]; ok {
+		return modifier
+	}
+	return strings.ToLower(l.Script)
+}
+
+// String returns the locale in POSIX form, e.g. "sr_RS.utf8@latin".
+func (l Locale) String() string {
+	ret := l.Language
+	if len(l.Territory) > 0 {
+		ret += "_" + l.Territory
+	}
+	if len(l.Codeset) > 0 {
+		ret += "." + l.Codeset
+	}
+	if modifier := l.modifier(); len(modifier) > 0 {
+		ret += "@" + modifier
+	}
+	return ret
+}
+
+// Tag returns the locale as BCP 47 language tag, e.g. "sr-Latn-RS".
+// Codesets and POSIX modifiers are dropped.
+func (l Locale) Tag() string {
+	ret := l.Language
+	if len(l.Script) > 0 {
+		ret += "-" + l.Script
+	}
+	if len(l.Territory) > 0 {
+		ret += "-" + l.Territory
+	}
+	return ret
+}
+
+// Dirs returns the names of the directories to search for message catalogs
+// of the locale in order, from the most to the least specific one, like GNU
+// gettext does. Codesets are ignored as catalogs are expected to be encoded
+// in UTF-8.
+func (l Locale) Dirs() []string {
+	var ret []string
+	add := func(dir string) {
+		for _, existing := range ret {
+			if existing == dir {
+				return
+			}
+		}
+		ret = append(ret, dir)
+	}
+	modifier := l.modifier()
+	territory := ""
+	if len(l.Territory) > 0 {
+		territory = "_" + l.Territory
+	}
+	if len(modifier) > 0 {
+		add(l.Language + territory + "@" + modifier)
+	}
+	add(l.Language + territory)
+	if len(modifier) > 0 {
+		add(l.Language + "@" + modifier)
+	}
+	add(l.Language)
+	return ret
+}
+
+// localeKey returns the normalized form of the given locale identifier used
+// to store message catalogs, or the identifier itself if it can not be
+// parsed.
+func localeKey(locale string) string {
+	if isLocaleKey(locale) {
+		return locale
+	}
+	parsed, err := ParseLocale(locale)
+	if err != nil {
+		return locale
+	}
+	// Like parsed.Dirs()[0] without building the others.
+	key := parsed.Language
+	if len(parsed.Territory) > 0 {
+		key += "_" + parsed.Territory
+	}
+	if modifier := parsed.modifier(); len(modifier) > 0 {
+		key += "@" + modifier
+	}
+	return key
+}
+
+// isLocaleKey returns true if the given locale identifier is already in the
+// form returned by localeKey and has no modifier, e.g. "de" or "pt_BR". It
+// saves parsing the identifiers used most often.
+func isLocaleKey(locale string) bool {
+	language, territory, found := strings.Cut(locale, "_")
+	if n := len(language); n < 2 || n == 4 || n > 8 ||
+		strings.IndexFunc(language, func(r rune) bool {
+			return r < 'a' || r > 'z'
+		}) != -1 {
+		return false
+	}
+	if !found {
+		return true
+	}
+	return len(territory) == 2 && isAlpha(territory) &&
+		strings.ToUpper(territory) == territory ||
+		len(territory) == 3 && isDigit(territory)
+}
+
+// isPseudoLocale returns true if the given locale identifier refers to
+// PseudoLocale.
+func isPseudoLocale(locale string) bool {
+	return localeKey(locale) == localeKey(PseudoLocale)
+}
+
+// catalogDirs returns the names of the directories to search for message
+// catalogs of the given locale identifier in order like GNU gettext does: the
+// identifier itself, its normalized POSIX form with codeset and the ones
+// returned by Locale.Dirs.
+func catalogDirs(locale string) []string {
+	ret := []string{locale}
+	parsed, err := ParseLocale(locale)
+	if err != nil {
+		return ret
+	}
+	for _, dir := range append([]string{parsed.String()}, parsed.Dirs()...) {
+		found := false
+		for _, existing := range ret {
+			found = found || existing == dir
+		}
+		if !found {
+			ret = append(ret, dir)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,173 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		locale        string
+		parsed        Locale
+		posix, tag    string
+		dirs          []string
+		expectedError bool
+	}{
+		{"de", Locale{Language: "de"}, "de", "de", []string{"de"}, false},
+		{"de_DE", Locale{Language: "de", Territory: "DE"}, "de_DE", "de-DE",
+			[]string{"de_DE", "de"}, false},
+		{"DE-de", Locale{Language: "de", Territory: "DE"}, "de_DE", "de-DE",
+			[]string{"de_DE", "de"}, false},
+		{"de_DE.UTF-8@euro", Locale{Language: "de", Territory: "DE",
+			Codeset: "utf8", Modifier: "euro"}, "de_DE.utf8@euro", "de-DE",
+			[]string{"de_DE@euro", "de_DE", "de@euro", "de"}, false},
+		{"sr-Latn-RS", Locale{Language: "sr", Script: "Latn",
+			Territory: "RS"}, "sr_RS@latin", "sr-Latn-RS",
+			[]string{"sr_RS@latin", "sr_RS", "sr@latin", "sr"}, false},
+		{"es-419", Locale{Language: "es", Territory: "419"}, "es_419",
+			"es-419", []string{"es_419", "es"}, false},
+		{"de-CH-1901-x-private", Locale{Language: "de", Territory: "CH",
+			Modifier: "1901"}, "de_CH@1901", "de-CH",
+			[]string{"de_CH@1901", "de_CH", "de@1901", "de"}, false},
+		{"qps-ploc", Locale{Language: "qps", Script: "Ploc"}, "qps@ploc",
+			"qps-Ploc", []string{"qps@ploc", "qps"}, false},
+		{"", Locale{}, "", "", nil, true},
+		{"C", Locale{}, "", "", nil, true},
+		{"de_DEU_x", Locale{}, "", "", nil, true},
+		{"d3", Locale{}, "", "", nil, true},
+	}
+	for i, test := range tests {
+		parsed, err := ParseLocale(test.locale)
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("Test %v: Parsing %q should fail", i, test.locale)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %v: Could not parse %q: %v", i, test.locale, err)
+			continue
+		}
+		if parsed != test.parsed {
+			t.Errorf("Test %v: Parsed locale should be %+v, got %+v", i,
+				test.parsed, parsed)
+		}
+		if parsed.String() != test.posix {
+			t.Errorf("Test %v: String should be %q, got %q", i, test.posix,
+				parsed.String())
+		}
+		if parsed.Tag() != test.tag {
+			t.Errorf("Test %v: Tag should be %q, got %q", i, test.tag,
+				parsed.Tag())
+		}
+		if !reflect.DeepEqual(parsed.Dirs(), test.dirs) {
+			t.Errorf("Test %v: Dirs should be %v, got %v", i, test.dirs,
+				parsed.Dirs())
+		}
+	}
+}
+
+func TestLocaleNormalization(t *testing.T) {
+	locales := setupLocales(t)
+	locales.Use("test", "de-DE")
+	tests := []struct {
+		locale string
+	}{
+		{"de"}, {"de_DE"}, {"de-DE"}, {"de_DE.UTF-8"}, {"DE_de"}, {"de-AT"},
+	}
+	for i, test := range tests {
+		locales.Use("test", test.locale)
+		ret := locales.Singular("test", test.locale, "Message")
+		if ret != "Translated Message" {
+			t.Errorf("Test %v: Translation for %q should be %q, got %q", i,
+				test.locale, "Translated Message", ret)
+		}
+	}
+	if stats := locales.CacheStats(); stats.Catalogs != 3 {
+		t.Errorf("Three catalogs should have been loaded, got %d",
+			stats.Catalogs)
+	}
+	if ret := locales.Singular("test", "qps_PLOC", "Message"); ret != "Message" {
+		t.Errorf(`Unloaded pseudo locale should yield "Message", got %q`, ret)
+	}
+	locales.Use("test", "qps_PLOC")
+	if ret := locales.Singular("test", PseudoLocale, "Message"); ret !=
+		Pseudolocalize("Message") {
+		t.Errorf("Pseudo locale should be normalized, got %q", ret)
+	}
+}
+
+func TestLocaleDirectories(t *testing.T) {
+	catalog := func(msgstr string) *fstest.MapFile {
+		return &fstest.MapFile{
+			Data: makeMO(map[string]string{"Message": msgstr})}
+	}
+	fsys := fstest.MapFS{
+		"pt-BR/LC_MESSAGES/app.mo":       catalog("Brazilian Message"),
+		"de_DE.UTF-8/LC_MESSAGES/app.mo": catalog("UTF-8 Message"),
+		"de/LC_MESSAGES/app.mo":          catalog("German Message"),
+		"fr_FR@euro/LC_MESSAGES/app.mo":  catalog("Euro Message"),
+		"fr_FR/LC_MESSAGES/app.mo":       catalog("French Message"),
+	}
+	tests := []struct {
+		locale, translated string
+	}{
+		{"pt-BR", "Brazilian Message"},
+		{"pt_BR", "Brazilian Message"},
+		{"de_DE.UTF-8", "UTF-8 Message"},
+		{"de_DE.utf8", "UTF-8 Message"},
+		{"de_DE", "UTF-8 Message"},
+		{"de_AT.UTF-8", "German Message"},
+		{"fr_FR@euro", "Euro Message"},
+		{"fr_FR.ISO-8859-15@euro", "Euro Message"},
+		{"fr_FR", "French Message"},
+	}
+	for _, test := range tests {
+		locales := New(WithFS(fsys))
+		singular, _, _, _ := locales.Use("app", test.locale)
+		if ret := singular("Message"); ret != test.translated {
+			t.Errorf("Translation for %q should be %q, got %q", test.locale,
+				test.translated, ret)
+		}
+	}
+	locales, err := New(WithFS(fsys)).AvailableLocales("app")
+	want := []string{"de", "de_DE", "fr_FR", "fr_FR@euro", "pt_BR"}
+	if err != nil || !reflect.DeepEqual(locales, want) {
+		t.Errorf("AvailableLocales should return %v, got %v (%v)", want, locales,
+			err)
+	}
+}
+
+func TestLocaleKey(t *testing.T) {
+	for _, locale := range []string{"de", "pt_BR", "es_419", "de-DE",
+		"DE_de", "de_DE.UTF-8", "sr_RS@latin", "sr-Latn-RS", "gsw", "en_gb",
+		"Latn", "qps-ploc", "x", ""} {
+		key := localeKey(locale)
+		if parsed, err := ParseLocale(locale); err == nil &&
+			key != parsed.Dirs()[0] {
+			t.Errorf("Key of %q should be %q, got %q", locale, parsed.Dirs()[0],
+				key)
+		}
+		if again := localeKey(key); again != key {
+			t.Errorf("Key %q of %q should be normalized, got %q", key, locale,
+				again)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"time"
+)
+
+// logLoad logs the result of loading the message catalog for the given
+// domain and locale. Missing catalogs are logged at debug level, other
+// failures as errors and warnings of loaded catalogs as warnings.
+func logLoad(logger *slog.Logger, domain, locale, path string,
+	tr *translation, duration time.Duration, reload bool, err error) {
+	attrs := []slog.Attr{
+		slog.String("domain", domain),
+		slog.String("locale", locale),
+		slog.String("path", path),
+		slog.Duration("duration", duration),
+		slog.Bool("reload", reload),
+	}
+	ctx := context.Background()
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		logger.LogAttrs(ctx, slog.LevelDebug, "Message catalog not found",
+			append(attrs, slog.Any("error", err))...)
+	case err != nil:
+		logger.LogAttrs(ctx, slog.LevelError, "Could not load message catalog",
+			append(attrs, slog.Any("error", err))...)
+	default:
+		logger.LogAttrs(ctx, slog.LevelInfo, "Loaded message catalog",
+			append(attrs, slog.Int("entries", len(tr.msgs)))...)
+		for _, warning := range tr.warnings {
+			logger.LogAttrs(ctx, slog.LevelWarn, "Problem in message catalog",
+				append(attrs[:3:3], slog.Any("error", warning))...)
+		}
+	}
+}
+
+// logEvictions logs the given evicted message catalogs at debug level.
+func logEvictions(logger *slog.Logger, evicted []eviction) {
+	for _, e := range evicted {
+		logger.Debug("Evicted message catalog", "domain", e.key.domain,
+			"locale", e.key.locale, "size", e.size)
+	}
+}
+
+// logMissing logs a missing translation at debug level, sampled according
+// to LogMissingEvery.
+func (l *Locales) logMissing(logger *slog.Logger, domain, locale, msgctxt,
+	msgid string) {
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	l.mutex.RLock()
+	every := l.LogMissingEvery
+	l.mutex.RUnlock()
+	count := l.missing.Add(1)
+	if every > 1 && (count-1)%uint64(every) != 0 {
+		return
+	}
+	logger.Debug("Missing translation", "domain", domain, "locale", locale,
+		"context", msgctxt, "msgid", msgid)
+}
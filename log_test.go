@@ -0,0 +1,99 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLogger(t *testing.T) {
+	fsys := fstest.MapFS{
+		"de/LC_MESSAGES/app.mo": &fstest.MapFile{
+			Data: makeMO(map[string]string{"Message": "Nachricht"})},
+		"en/LC_MESSAGES/app.mo": &fstest.MapFile{
+			Data: makeMO(map[string]string{"Other": "Other Message"})},
+		"fr/LC_MESSAGES/app.mo": &fstest.MapFile{Data: []byte("broken")},
+	}
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buffer,
+		&slog.HandlerOptions{Level: slog.LevelDebug}))
+	locales := New(WithFS(fsys), WithFallbacks("en"), WithLogger(logger))
+	locales.LogMissingEvery = 2
+	locales.Use("app", "de_DE")
+	locales.Use("app", "fr")
+	locales.Use("app", "xx")
+	locales.Singular("app", "de_DE", "Other")
+	for i := 0; i < 3; i++ {
+		locales.ContextSingular("app", "de_DE", "Menu", "Unknown")
+	}
+	logged := buffer.String()
+	tests := []struct {
+		line  string
+		count int
+	}{
+		{`level=INFO msg="Loaded message catalog" domain=app locale=de_DE ` +
+			`path=de/LC_MESSAGES/app.mo`, 1},
+		{`level=INFO msg="Loaded message catalog" domain=app locale=en`, 1},
+		{`level=ERROR msg="Could not load message catalog" domain=app ` +
+			`locale=fr`, 1},
+		{`level=DEBUG msg="Message catalog not found" domain=app locale=xx`, 1},
+		{`level=DEBUG msg="Using fallback translation" domain=app locale=de_DE ` +
+			`fallback=en msgid=Other`, 1},
+		{`level=DEBUG msg="Missing translation" domain=app locale=de_DE ` +
+			`context=Menu msgid=Unknown`, 2},
+	}
+	for i, test := range tests {
+		if count := strings.Count(logged, test.line); count != test.count {
+			t.Errorf("Test %v: %q should be logged %v times, got %v in:\n%s",
+				i, test.line, test.count, count, logged)
+		}
+	}
+}
+
+func TestLoggerPaths(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buffer,
+		&slog.HandlerOptions{Level: slog.LevelDebug}))
+	locales := New(WithLocaleDir("test_locale"), WithLogger(logger))
+	locales.MaxCatalogs = 1
+	locales.Use("test", "de_AT")
+	locales.Use("test", "fr")
+	logged := buffer.String()
+	for _, line := range []string{
+		`msg="Loaded message catalog" domain=test locale=de_AT ` +
+			`path=` + filepath.Join("test_locale", "de", "LC_MESSAGES", "test.mo"),
+		`msg="Message catalog not found" domain=test locale=fr ` +
+			`path=` + filepath.Join("test_locale", "fr", "LC_MESSAGES", "test.mo"),
+	} {
+		if !strings.Contains(logged, line) {
+			t.Errorf("%q should be logged, got:\n%s", line, logged)
+		}
+	}
+	if err := locales.Add("test", "en", NewCatalog()); err != nil {
+		t.Fatalf("Could not add catalog: %v", err)
+	}
+	locales.Use("test", "de")
+	line := `msg="Evicted message catalog" domain=test locale=de_AT`
+	if !strings.Contains(buffer.String(), line) {
+		t.Errorf("%q should be logged, got:\n%s", line, buffer.String())
+	}
+}
@@ -0,0 +1,102 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"io/fs"
+	"log/slog"
+)
+
+// Option configures a Locales created by New.
+type Option func(*Locales)
+
+// New returns a new Locales configured by the given options.
+func New(opts ...Option) *Locales {
+	l := &Locales{}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithLocaleDir sets the directory to search for message catalogs.
+func WithLocaleDir(dir string) Option {
+	return func(l *Locales) {
+		l.LocaleDir = dir
+	}
+}
+
+// WithFS sets the file system to search for message catalogs. Use
+// WithLocaleDir to set the directory within.
+func WithFS(fsys fs.FS) Option {
+	return func(l *Locales) {
+		l.FS = fsys
+	}
+}
+
+// WithDomain sets the default domain.
+func WithDomain(domain string) Option {
+	return func(l *Locales) {
+		l.Domain = domain
+	}
+}
+
+// WithLocale sets the default locale.
+func WithLocale(locale string) Option {
+	return func(l *Locales) {
+		l.Locale = locale
+	}
+}
+
+// WithFallbacks sets the locales to consult for missing messages.
+func WithFallbacks(locales ...string) Option {
+	return func(l *Locales) {
+		l.Fallbacks = append([]string(nil), locales...)
+	}
+}
+
+// WithLoadHook sets the function to call after each attempt to load a
+// message catalog.
+func WithLoadHook(hook func(domain, locale string, err error)) Option {
+	return func(l *Locales) {
+		l.OnLoad = hook
+	}
+}
+
+// WithMissingHook sets the function to call for each lookup of a message
+// without translation.
+func WithMissingHook(hook func(domain, locale, context, msgid string)) Option {
+	return func(l *Locales) {
+		l.OnMissing = hook
+	}
+}
+
+// WithInstrumentation sets the instrumentation receiving lookup and load
+// events.
+func WithInstrumentation(inst Instrumentation) Option {
+	return func(l *Locales) {
+		l.Instrumentation = inst
+	}
+}
+
+// WithLogger sets the logger receiving structured events about message
+// catalogs and missing translations.
+func WithLogger(logger *slog.Logger) Option {
+	return func(l *Locales) {
+		l.Logger = logger
+	}
+}
@@ -0,0 +1,98 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNew(t *testing.T) {
+	fsys := fstest.MapFS{
+		"locale/de/LC_MESSAGES/app.mo": &fstest.MapFile{
+			Data: makeMO(map[string]string{"Message": "Nachricht"})},
+		"locale/en/LC_MESSAGES/app.mo": &fstest.MapFile{
+			Data: makeMO(map[string]string{
+				"Message":           "Message",
+				"Other":             "Other Message",
+				"Menu\x04Other":     "Other Menu Message",
+				"File\x00Files":     "File\x00Files",
+				"Missing\x00Misses": "Missed\x00Misses",
+			})},
+	}
+	type event struct {
+		domain, locale, context, msgid string
+	}
+	var loads []string
+	var missing []event
+	locales := New(
+		WithFS(fsys),
+		WithLocaleDir("locale"),
+		WithDomain("app"),
+		WithLocale("de_DE"),
+		WithFallbacks("de", "en"),
+		WithLoadHook(func(domain, locale string, err error) {
+			loads = append(loads, domain+"/"+locale)
+		}),
+		WithMissingHook(func(domain, locale, context, msgid string) {
+			missing = append(missing, event{domain, locale, context, msgid})
+		}))
+	tr := locales.Translator("", "")
+	if tr.Domain() != "app" || tr.Locale() != "de_DE" {
+		t.Errorf("Translator should use the defaults, got %q and %q",
+			tr.Domain(), tr.Locale())
+	}
+	tests := []struct {
+		ret, translated string
+	}{
+		{tr.Singular("Message"), "Nachricht"},
+		{tr.Singular("Other"), "Other Message"},
+		{tr.ContextSingular("Menu", "Other"), "Other Menu Message"},
+		{tr.Plural("Missing", "Misses", 1), "Missed"},
+		{tr.Singular("Unknown"), "Unknown"},
+		{tr.ContextSingular("Menu", "Unknown"), "Unknown"},
+	}
+	for i, test := range tests {
+		if test.ret != test.translated {
+			t.Errorf("Test %v: Translation should be %q, got %q", i,
+				test.translated, test.ret)
+		}
+	}
+	if len(loads) != 3 || loads[0] != "app/de_DE" {
+		t.Errorf("Unexpected loads: %v", loads)
+	}
+	if len(missing) != 2 ||
+		missing[0] != (event{"app", "de_DE", "", "Unknown"}) ||
+		missing[1] != (event{"app", "de_DE", "Menu", "Unknown"}) {
+		t.Errorf("Unexpected missing messages: %v", missing)
+	}
+}
+
+func TestUseConcurrent(t *testing.T) {
+	defer func() { DefaultLocales = Locales{} }()
+	var wg sync.WaitGroup
+	for _, locale := range []string{"de", "fr", "en"} {
+		wg.Add(1)
+		go func(locale string) {
+			defer wg.Done()
+			singular, _, _, _ := Use("test_locale", "test", locale)
+			singular("Message")
+		}(locale)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,82 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"fmt"
+)
+
+// AddOverlay installs the given catalog as overlay for the given domain and
+// locale in the given layer, e.g. a tenant or deployment. Overlays are
+// consulted before the base catalog by translators and translation functions
+// requesting the layer, see UseLayers and Translator.WithLayers. Catalogs
+// added to the same layer before are merged.
+//
+// Unless the catalog or one added to the layer before has a plural rule, the
+// one of the base catalog is used, which is loaded if necessary.
+func (l *Locales) AddOverlay(layer, domain, locale string, c *Catalog) error {
+	if c == nil {
+		return fmt.Errorf("Catalog must not be nil")
+	}
+	base, _ := l.get(domain, locale)
+	locale = localeKey(locale)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.overlays == nil {
+		l.overlays = make(map[string]map[string]map[string]*translation)
+	}
+	if _, ok := l.overlays[layer]; !ok {
+		l.overlays[layer] = make(map[string]map[string]*translation)
+	}
+	if _, ok := l.overlays[layer][domain]; !ok {
+		l.overlays[layer][domain] = make(map[string]*translation)
+	}
+	previous := l.overlays[layer][domain][locale]
+	overlay := c.merge(previous, locale)
+	if c.rule == nil && previous == nil && base != nil {
+		overlay.plural = base.plural
+		overlay.cldr, overlay.cldrIndexes = base.cldr, base.cldrIndexes
+	}
+	l.overlays[layer][domain][locale] = overlay
+	return nil
+}
+
+// RemoveLayer removes all overlays of the given layer.
+func (l *Locales) RemoveLayer(layer string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.overlays, layer)
+}
+
+// UseLayers is like Use, but the returned translation functions consult the
+// overlays of the given layers in order before the base catalogs.
+func (l *Locales) UseLayers(domain, locale string, layers ...string) (
+	Singular, Plural, DomainSingular, DomainPlural) {
+	tr := l.Translator(domain, locale).WithLayers(layers...)
+	return tr.Singular, tr.Plural, tr.DomainSingular, tr.DomainPlural
+}
+
+// WithLayers returns a copy of the translator consulting the overlays of the
+// given layers in order before the base catalogs.
+func (t *Translator) WithLayers(layers ...string) *Translator {
+	if t == nil {
+		return nil
+	}
+	ret := *t
+	ret.layers = append([]string(nil), layers...)
+	return &ret
+}
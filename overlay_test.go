@@ -0,0 +1,94 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"testing"
+)
+
+func TestOverlay(t *testing.T) {
+	locales := setupLocales(t)
+	tenant := NewCatalog()
+	tenant.Add("Message", "Tenant Message")
+	tenant.AddPlural("Singular", "Plural", "Tenant Singular", "Tenant Plural")
+	if err := locales.AddOverlay("tenant", "test", "de", tenant); err != nil {
+		t.Fatalf("Could not add overlay: %v", err)
+	}
+	deployment := NewCatalog()
+	deployment.Add("Message", "Deployment Message")
+	deployment.AddContext("Menu", "Message", "Deployment Menu Message")
+	if err := locales.AddOverlay("deployment", "test", "de",
+		deployment); err != nil {
+		t.Fatalf("Could not add overlay: %v", err)
+	}
+	if err := locales.AddOverlay("tenant", "test", "de", nil); err == nil {
+		t.Errorf("Adding a nil overlay should fail")
+	}
+	base := locales.Translator("test", "de")
+	tr := base.WithLayers("tenant", "deployment")
+	deploymentFirst := base.WithLayers("deployment", "tenant")
+	singular, plural, _, _ := locales.UseLayers("test", "de", "tenant")
+	tests := []struct {
+		ret, translated string
+	}{
+		{base.Singular("Message"), "Translated Message"},
+		{tr.Singular("Message"), "Tenant Message"},
+		{deploymentFirst.Singular("Message"), "Deployment Message"},
+		{tr.Plural("Singular", "Plural", 1), "Tenant Singular"},
+		{tr.Plural("Singular", "Plural", 2), "Tenant Plural"},
+		{tr.ContextSingular("Menu", "Message"), "Deployment Menu Message"},
+		{tr.ContextPlural("Mailbox", "Message", "Messages", 2),
+			base.ContextPlural("Mailbox", "Message", "Messages", 2)},
+		{tr.Singular("Unknown"), "Unknown"},
+		{singular("Message"), "Tenant Message"},
+		{plural("Singular", "Plural", 2), "Tenant Plural"},
+		{base.WithLayers("unknown").Singular("Message"), "Translated Message"},
+	}
+	for i, test := range tests {
+		if test.ret != test.translated {
+			t.Errorf("Test %v: Translation should be %q, got %q", i,
+				test.translated, test.ret)
+		}
+	}
+	locales.RemoveLayer("tenant")
+	if ret := tr.Singular("Message"); ret != "Deployment Message" {
+		t.Errorf(`Translation should be "Deployment Message", got %q`, ret)
+	}
+}
+
+func TestOverlayPluralRule(t *testing.T) {
+	locales := setupLocales(t)
+	first := NewCatalog()
+	first.AddPlural("File", "Files", "One", "Few", "Many")
+	rule, err := ParsePluralForms("nplurals=3; plural=n == 1 ? 0 : n < 5 ? 1 : 2;")
+	if err != nil {
+		t.Fatalf("Could not parse plural forms: %v", err)
+	}
+	first.SetPluralRule(rule)
+	if err := locales.AddOverlay("tenant", "test", "de", first); err != nil {
+		t.Fatalf("Could not add overlay: %v", err)
+	}
+	second := NewCatalog()
+	second.Add("Message", "Tenant Message")
+	if err := locales.AddOverlay("tenant", "test", "de", second); err != nil {
+		t.Fatalf("Could not add overlay: %v", err)
+	}
+	tr := locales.Translator("test", "de").WithLayers("tenant")
+	if ret := tr.Plural("File", "Files", 3); ret != "Few" {
+		t.Errorf(`Translation for 3 should be "Few", got %q`, ret)
+	}
+}
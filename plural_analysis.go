@@ -0,0 +1,126 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// pluralAnalysisRange is the range of consecutive values of n, starting with
+// zero, evaluated by Analyze.
+const pluralAnalysisRange = 10000
+
+// pluralExamples is the maximum number of examples per plural form.
+const pluralExamples = 5
+
+// PluralAnalysis is the result of analyzing a plural rule.
+type PluralAnalysis struct {
+	// Examples contains the smallest values of n for each plural form.
+	Examples [][]uint64
+	// Unreachable contains the plural forms which are never selected.
+	Unreachable []int
+	// OutOfRange maps indexes the expression returns, but which exceed the
+	// number of plural forms, to the smallest values of n producing them.
+	OutOfRange map[uint64][]uint64
+}
+
+// Valid returns true iff all plural forms are reachable and none of the
+// indexes are out of range.
+func (a *PluralAnalysis) Valid() bool {
+	return len(a.Unreachable) == 0 && len(a.OutOfRange) == 0
+}
+
+func (a *PluralAnalysis) String() string {
+	var lines []string
+	for i, examples := range a.Examples {
+		lines = append(lines, fmt.Sprintf("form %d: %v", i,
+			formatExamples(examples)))
+	}
+	if len(a.Unreachable) > 0 {
+		lines = append(lines, fmt.Sprintf("unreachable forms: %v",
+			a.Unreachable))
+	}
+	indexes := make([]uint64, 0, len(a.OutOfRange))
+	for index := range a.OutOfRange {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	for _, index := range indexes {
+		lines = append(lines, fmt.Sprintf("index %d out of range: %v", index,
+			formatExamples(a.OutOfRange[index])))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatExamples formats the given examples like "1, 21, 31, …".
+func formatExamples(examples []uint64) string {
+	if len(examples) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(examples))
+	for i, n := range examples {
+		parts[i] = fmt.Sprint(n)
+	}
+	if len(examples) == pluralExamples {
+		parts = append(parts, "…")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Analyze evaluates the rule for n in [0, 10000] and some larger samples
+// like powers of ten and two. It reports unreachable plural forms, indexes
+// out of range and example values of n for each plural form, e.g. for
+// translator documentation.
+func (r PluralRule) Analyze() *PluralAnalysis {
+	analysis := PluralAnalysis{
+		Examples:   make([][]uint64, r.NPlurals),
+		OutOfRange: make(map[uint64][]uint64),
+	}
+	add := func(n uint64) {
+		if r.pf == nil {
+			return
+		}
+		index := r.pf(n)
+		if index < uint64(r.NPlurals) {
+			if len(analysis.Examples[index]) < pluralExamples {
+				analysis.Examples[index] = append(analysis.Examples[index], n)
+			}
+			return
+		}
+		if len(analysis.OutOfRange[index]) < pluralExamples {
+			analysis.OutOfRange[index] = append(analysis.OutOfRange[index], n)
+		}
+	}
+	for n := uint64(0); n <= pluralAnalysisRange; n++ {
+		add(n)
+	}
+	samples := peSamples()
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	for i, n := range samples {
+		if n > pluralAnalysisRange && (i == 0 || samples[i-1] != n) {
+			add(n)
+		}
+	}
+	for i, examples := range analysis.Examples {
+		if len(examples) == 0 {
+			analysis.Unreachable = append(analysis.Unreachable, i)
+		}
+	}
+	return &analysis
+}
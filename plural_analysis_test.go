@@ -0,0 +1,60 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		header      string
+		examples    [][]uint64
+		unreachable []int
+		outOfRange  map[uint64][]uint64
+	}{
+		{"nplurals=2; plural=n != 1;",
+			[][]uint64{{1}, {0, 2, 3, 4, 5}}, nil, map[uint64][]uint64{}},
+		{pluralFormsTable["ru"],
+			[][]uint64{{1, 21, 31, 41, 51}, {2, 3, 4, 22, 23},
+				{0, 5, 6, 7, 8}}, nil, map[uint64][]uint64{}},
+		{"nplurals=3; plural=n == 1 ? 0 : 1;",
+			[][]uint64{{1}, {0, 2, 3, 4, 5}, nil}, []int{2},
+			map[uint64][]uint64{}},
+		{"nplurals=2; plural=n > 20000 ? 2 : n == 1 ? 0 : 1;",
+			[][]uint64{{1}, {0, 2, 3, 4, 5}}, nil,
+			map[uint64][]uint64{2: {32767, 32768, 32769, 65535, 65536}}},
+	}
+	for _, test := range tests {
+		rule, err := ParsePluralForms(test.header)
+		if err != nil {
+			t.Errorf("Could not parse %q: %v", test.header, err)
+			continue
+		}
+		analysis := rule.Analyze()
+		if !reflect.DeepEqual(analysis.Examples, test.examples) ||
+			!reflect.DeepEqual(analysis.Unreachable, test.unreachable) ||
+			!reflect.DeepEqual(analysis.OutOfRange, test.outOfRange) {
+			t.Errorf("Unexpected analysis of %q:\n%v", test.header, analysis)
+		}
+		if analysis.Valid() != (test.unreachable == nil &&
+			len(test.outOfRange) == 0) {
+			t.Errorf("Analysis of %q should not be valid", test.header)
+		}
+	}
+}
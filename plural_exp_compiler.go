@@ -0,0 +1,386 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"fmt"
+)
+
+// Additional operators only used by compiled plural form expressions. Jumps
+// take the index of the target instruction as argument.
+const (
+	peJump peOp = peCond + 1 + iota
+	peJumpIfZero
+	peJumpIfNonZero
+	peBool
+)
+
+// peTableSize is the number of precomputed results of compiled plural form
+// expressions, starting with n = 0.
+const peTableSize = 1000
+
+// peInstr is an instruction of a compiled plural form expression.
+type peInstr struct {
+	op  peOp
+	arg uint64
+	// imm is true iff arg is the second argument of a binary operator,
+	// instead of being on the stack.
+	imm bool
+	// lhsN is true iff the first argument of a binary operator is n, or n
+	// modulo mod if mod is not zero, instead of being on the stack. This
+	// covers the common "n % 10 == 1" in a single instruction.
+	lhsN bool
+	mod  uint64
+	// branch is set for comparisons followed by a conditional jump, which
+	// are then run as one instruction jumping to target.
+	branch peOp
+	target uint64
+}
+
+// peProgram is a compiled plural form expression to be run by a stack
+// machine. Operators pop their arguments from the stack and push their
+// result.
+type peProgram struct {
+	code []peInstr
+	// depth is the maximum stack depth needed to run the program.
+	depth int
+}
+
+// peApply applies the given binary operator.
+func peApply(op peOp, a, b uint64) uint64 {
+	var ret bool
+	switch op {
+	case peMod:
+		if b == 0 {
+			return 0
+		}
+		return a % b
+	case peEq:
+		ret = a == b
+	case peNe:
+		ret = a != b
+	case peLt:
+		ret = a < b
+	case peLe:
+		ret = a <= b
+	case peGt:
+		ret = a > b
+	case peGe:
+		ret = a >= b
+	}
+	if ret {
+		return 1
+	}
+	return 0
+}
+
+// peBoolean returns 1 if the given value is non-zero, 0 otherwise.
+func peBoolean(value uint64) uint64 {
+	if value != 0 {
+		return 1
+	}
+	return 0
+}
+
+// isComparison returns true if the given operator is a comparison.
+func isComparison(op peOp) bool {
+	switch op {
+	case peEq, peNe, peLt, peLe, peGt, peGe:
+		return true
+	}
+	return false
+}
+
+// isBooleanPE returns true if the given node always evaluates to 0 or 1.
+func isBooleanPE(node *peNode) bool {
+	return isComparison(node.op) || node.op == peAnd || node.op == peOr
+}
+
+// foldPE returns a copy of the given syntax tree with constant subexpressions
+// replaced by their values.
+func foldPE(node *peNode) *peNode {
+	if len(node.args) == 0 {
+		return node
+	}
+	args := make([]*peNode, len(node.args))
+	for i, arg := range node.args {
+		args[i] = foldPE(arg)
+	}
+	fst := args[0]
+	switch node.op {
+	case peCond:
+		if fst.op == peConst {
+			if fst.value != 0 {
+				return args[1]
+			}
+			return args[2]
+		}
+	case peAnd, peOr:
+		if fst.op == peConst {
+			if (fst.value != 0) == (node.op == peOr) {
+				return &peNode{op: peConst, value: peBoolean(fst.value)}
+			}
+			if args[1].op == peConst {
+				return &peNode{op: peConst, value: peBoolean(args[1].value)}
+			}
+		}
+	default:
+		// Division by zero is left to the evaluation to be reported.
+		if fst.op == peConst && args[1].op == peConst &&
+			(node.op != peMod || args[1].value != 0) {
+			return &peNode{op: peConst,
+				value: peApply(node.op, fst.value, args[1].value)}
+		}
+	}
+	return &peNode{op: node.op, args: args}
+}
+
+// compilePE folds the given syntax tree and compiles it into a program.
+func compilePE(node *peNode) *peProgram {
+	var p peProgram
+	var depth int
+	emit := func(op peOp, arg uint64, change int) int {
+		p.code = append(p.code, peInstr{op: op, arg: arg})
+		depth += change
+		if depth > p.depth {
+			p.depth = depth
+		}
+		return len(p.code) - 1
+	}
+	var compile func(node *peNode)
+	compile = func(node *peNode) {
+		switch node.op {
+		case peN:
+			emit(peN, 0, 1)
+		case peConst:
+			emit(peConst, node.value, 1)
+		case peCond, peAnd, peOr:
+			// Conditions are compiled to jumps to short circuit.
+			compile(node.args[0])
+			branch := emit(peJumpIfZero, 0, -1)
+			if node.op == peOr {
+				p.code[branch].op = peJumpIfNonZero
+			}
+			compile(node.args[1])
+			if node.op != peCond && !isBooleanPE(node.args[1]) {
+				emit(peBool, 0, 0)
+			}
+			jump := emit(peJump, 0, -1)
+			p.code[branch].arg = uint64(len(p.code))
+			switch node.op {
+			case peCond:
+				compile(node.args[2])
+			case peAnd:
+				emit(peConst, 0, 1)
+			case peOr:
+				emit(peConst, 1, 1)
+			}
+			p.code[jump].arg = uint64(len(p.code))
+		default:
+			instr := peInstr{op: node.op}
+			change := -1
+			lhs, rhs := node.args[0], node.args[1]
+			switch {
+			case lhs.op == peN:
+				instr.lhsN = true
+				change++
+			case lhs.op == peMod && lhs.args[0].op == peN &&
+				lhs.args[1].op == peConst && lhs.args[1].value != 0:
+				instr.lhsN, instr.mod = true, lhs.args[1].value
+				change++
+			default:
+				compile(lhs)
+			}
+			if rhs.op == peConst {
+				instr.arg, instr.imm = rhs.value, true
+				change++
+			} else {
+				compile(rhs)
+			}
+			emit(node.op, 0, change)
+			p.code[len(p.code)-1] = instr
+		}
+	}
+	compile(foldPE(node))
+	// Fuse comparisons with the conditional jumps following them. The jumps
+	// are kept as other jumps may target them.
+	for i := 0; i+1 < len(p.code); i++ {
+		next := p.code[i+1]
+		if isComparison(p.code[i].op) &&
+			(next.op == peJumpIfZero || next.op == peJumpIfNonZero) {
+			p.code[i].branch, p.code[i].target = next.op, next.arg
+		}
+	}
+	return &p
+}
+
+// eval runs the program for the given n. It returns false if the program
+// divided by zero, in which case the division evaluated to zero.
+func (p *peProgram) eval(n uint64) (uint64, bool) {
+	var buffer [16]uint64
+	stack := buffer[:]
+	if p.depth > len(buffer) {
+		stack = make([]uint64, p.depth)
+	}
+	ok := true
+	// sp is the index of the top of the stack.
+	sp := -1
+	code := p.code
+	for pc := 0; pc < len(code); pc++ {
+		instr := &code[pc]
+		var a, b uint64
+		switch instr.op {
+		case peN:
+			sp++
+			stack[sp] = n
+			continue
+		case peConst:
+			sp++
+			stack[sp] = instr.arg
+			continue
+		case peJump:
+			pc = int(instr.arg) - 1
+			continue
+		case peJumpIfZero:
+			if stack[sp] == 0 {
+				pc = int(instr.arg) - 1
+			}
+			sp--
+			continue
+		case peJumpIfNonZero:
+			if stack[sp] != 0 {
+				pc = int(instr.arg) - 1
+			}
+			sp--
+			continue
+		case peBool:
+			stack[sp] = peBoolean(stack[sp])
+			continue
+		}
+		// Binary operators
+		b = instr.arg
+		if !instr.imm {
+			b = stack[sp]
+			sp--
+		}
+		if instr.lhsN {
+			a = n
+			if instr.mod != 0 {
+				a %= instr.mod
+			}
+			sp++
+		} else {
+			a = stack[sp]
+		}
+		var ret bool
+		switch instr.op {
+		case peMod:
+			if b == 0 {
+				ok = false
+				stack[sp] = 0
+			} else {
+				stack[sp] = a % b
+			}
+			continue
+		case peEq:
+			ret = a == b
+		case peNe:
+			ret = a != b
+		case peLt:
+			ret = a < b
+		case peLe:
+			ret = a <= b
+		case peGt:
+			ret = a > b
+		case peGe:
+			ret = a >= b
+		}
+		switch {
+		case instr.branch == peJumpIfZero:
+			sp--
+			if !ret {
+				pc = int(instr.target) - 1
+			} else {
+				pc++
+			}
+		case instr.branch == peJumpIfNonZero:
+			sp--
+			if ret {
+				pc = int(instr.target) - 1
+			} else {
+				pc++
+			}
+		case ret:
+			stack[sp] = 1
+		default:
+			stack[sp] = 0
+		}
+	}
+	return stack[0], ok
+}
+
+// peSamples returns values of n beyond the precomputed ones at which plural
+// form expressions are checked: powers of ten and two and their neighbours.
+func peSamples() []uint64 {
+	var samples []uint64
+	for power := uint64(peTableSize); power <= 1e19; power *= 10 {
+		samples = append(samples, power-1, power, power+1)
+		if power > (1<<64-1)/10 {
+			break
+		}
+	}
+	for shift := uint(10); shift < 64; shift++ {
+		samples = append(samples, 1<<shift-1, 1<<shift, 1<<shift+1)
+	}
+	return append(samples, 1<<64-1)
+}
+
+// check returns an error if the program divides by zero for any n in [0,
+// peTableSize) or any of the samples returned by peSamples.
+func (p *peProgram) check() error {
+	for n := uint64(0); n < peTableSize; n++ {
+		if _, ok := p.eval(n); !ok {
+			return fmt.Errorf("Division by zero for n = %d", n)
+		}
+	}
+	for _, n := range peSamples() {
+		if _, ok := p.eval(n); !ok {
+			return fmt.Errorf("Division by zero for n = %d", n)
+		}
+	}
+	return nil
+}
+
+// pluralForm returns a pluralForm function running the program. Results for
+// small n are precomputed.
+func (p *peProgram) pluralForm() pluralForm {
+	if len(p.code) == 1 && p.code[0].op == peConst {
+		value := p.code[0].arg
+		return func(n uint64) uint64 { return value }
+	}
+	table := make([]uint64, peTableSize)
+	for n := range table {
+		table[n], _ = p.eval(uint64(n))
+	}
+	return func(n uint64) uint64 {
+		if n < peTableSize {
+			return table[n]
+		}
+		ret, _ := p.eval(n)
+		return ret
+	}
+}
@@ -0,0 +1,175 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"testing"
+)
+
+// closurePE builds a tree of closures evaluating the given syntax tree, as
+// the parser used to do. It serves as reference implementation.
+func closurePE(node *peNode) pluralForm {
+	switch node.op {
+	case peN:
+		return func(n uint64) uint64 { return n }
+	case peConst:
+		return func(n uint64) uint64 { return node.value }
+	case peCond:
+		c, a, b := closurePE(node.args[0]), closurePE(node.args[1]),
+			closurePE(node.args[2])
+		return func(n uint64) uint64 {
+			if c(n) > 0 {
+				return a(n)
+			}
+			return b(n)
+		}
+	case peAnd:
+		a, b := closurePE(node.args[0]), closurePE(node.args[1])
+		return func(n uint64) uint64 {
+			if a(n) > 0 && b(n) > 0 {
+				return 1
+			}
+			return 0
+		}
+	case peOr:
+		a, b := closurePE(node.args[0]), closurePE(node.args[1])
+		return func(n uint64) uint64 {
+			if a(n) > 0 || b(n) > 0 {
+				return 1
+			}
+			return 0
+		}
+	}
+	a, b := closurePE(node.args[0]), closurePE(node.args[1])
+	return func(n uint64) uint64 {
+		return peApply(node.op, a(n), b(n))
+	}
+}
+
+// russianPE is a plural form expression of typical complexity.
+const russianPE = "n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && " +
+	"(n%100<10 || n%100>=20) ? 1 : 2"
+
+func TestCompilePE(t *testing.T) {
+	expressions := []string{
+		russianPE,
+		"n == 0 ? 0 : n == 1 ? 1 : n == 2 ? 2 : n%100 >= 3 && n%100 <= 10 ? 3" +
+			" : n%100 >= 11 ? 4 : 5",
+		"n > 1",
+		"(n == 1 || n % 10 == 1) && 1 ? 0 : 1",
+		"0 && n || 1",
+		"n != 0 && 10 % n == 1",
+		"(n > 5 ? n < 8 : n == 1) ? 0 : 1",
+		"n == 1 || n == 2 || n == 3 ? 4 : n > 10 && n % 7 != 0",
+		"n % 10 < n % 100 && (n % 3) % 2 == 1",
+	}
+	var parser peParser
+	for _, exp := range expressions {
+		node, err := parser.parse([]byte(exp))
+		if err != nil {
+			t.Fatalf("Could not parse %q: %v", exp, err)
+		}
+		reference, program := closurePE(node), compilePE(node)
+		compiled := program.pluralForm()
+		for _, n := range []uint64{0, 1, 2, 3, 5, 6, 7, 8, 11, 12, 21, 22,
+			101, 111, 999, 1000, 1001, 1011, 1 << 40, 1<<64 - 1} {
+			want := reference(n)
+			if ret, _ := program.eval(n); ret != want {
+				t.Errorf("%q for n = %d should be %d, got %d", exp, n, want, ret)
+			}
+			if ret := compiled(n); ret != want {
+				t.Errorf("Compiled %q for n = %d should be %d, got %d", exp, n,
+					want, ret)
+			}
+		}
+	}
+}
+
+func TestFoldPE(t *testing.T) {
+	tests := []struct {
+		exp  string
+		code int
+	}{
+		{"(10 % 4) == 2 ? n : 3", 1},
+		{"1 || n", 1},
+		{"0 && n", 1},
+		{"1 && 2", 1},
+		{"n % 0", 1},
+		{"n > (2 != 3)", 1},
+		{"n > n", 2},
+		{"n % 10 == 1", 1},
+		{"n % 10 < n % 100", 2},
+		{"(n % 10 == 1) == 0", 2},
+	}
+	var parser peParser
+	for _, test := range tests {
+		node, err := parser.parse([]byte(test.exp))
+		if err != nil {
+			t.Fatalf("Could not parse %q: %v", test.exp, err)
+		}
+		if code := compilePE(node).code; len(code) != test.code {
+			t.Errorf("%q should compile to %d instructions, got %v", test.exp,
+				test.code, code)
+		}
+	}
+}
+
+// benchmarkPE benchmarks the given compile function for n in [start,
+// start+2000).
+func benchmarkPE(b *testing.B, start uint64,
+	compile func(node *peNode) pluralForm) {
+	var parser peParser
+	node, err := parser.parse([]byte(russianPE))
+	if err != nil {
+		b.Fatalf("Could not parse: %v", err)
+	}
+	pf := compile(node)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pf(start + uint64(i%2000))
+	}
+}
+
+func BenchmarkClosurePE(b *testing.B) {
+	benchmarkPE(b, 0, closurePE)
+}
+
+func BenchmarkClosurePELarge(b *testing.B) {
+	benchmarkPE(b, peTableSize, closurePE)
+}
+
+func BenchmarkEvalPE(b *testing.B) {
+	benchmarkPE(b, 0, func(node *peNode) pluralForm {
+		program := compilePE(node)
+		return func(n uint64) uint64 {
+			ret, _ := program.eval(n)
+			return ret
+		}
+	})
+}
+
+func BenchmarkCompiledPE(b *testing.B) {
+	benchmarkPE(b, 0, func(node *peNode) pluralForm {
+		return compilePE(node).pluralForm()
+	})
+}
+
+func BenchmarkCompiledPELarge(b *testing.B) {
+	benchmarkPE(b, peTableSize, func(node *peNode) pluralForm {
+		return compilePE(node).pluralForm()
+	})
+}
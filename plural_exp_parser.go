@@ -22,16 +22,53 @@ import (
 	"strconv"
 )
 
+// Limits of plural form expressions accepted by the parser.
+const (
+	// peMaxLength is the maximum length of an expression.
+	peMaxLength = 1024
+	// peMaxDepth is the maximum nesting depth of expressions.
+	peMaxDepth = 64
+)
+
 // peParser parses plural form expression used in gettext catalogs.
 type peParser struct {
 	// Unparesed expression
 	exp []byte
 	// Last parsed symbol
 	sym []byte
+	// Current nesting depth
+	depth int
 }
 
-// pluralForm maps an n to an index.
-type pluralForm func(n int) int
+// pluralForm maps an n to an index. Like GNU gettext, expressions are
+// evaluated using unsigned long arithmetic.
+type pluralForm func(n uint64) uint64
+
+// peOp is an operator of a plural form expression.
+type peOp byte
+
+const (
+	peN peOp = iota
+	peConst
+	peMod
+	peEq
+	peNe
+	peLt
+	peLe
+	peGt
+	peGe
+	peAnd
+	peOr
+	peCond
+)
+
+// peNode is a node of the syntax tree of a plural form expression.
+type peNode struct {
+	op peOp
+	// value of constants
+	value uint64
+	args  []*peNode
+}
 
 // A parser Error
 type pError struct {
@@ -71,153 +108,131 @@ func (p *peParser) accept(sym string) bool {
 // expect tries to parse the given symbol in front of the expression or errors
 // if it's not there.
 func (p *peParser) expect(sym string) {
-	p.dewhitespace()
-	if bytes.Equal(p.exp[:len(sym)], []byte(sym)) {
-		p.sym = []byte(sym)
-		p.exp = p.exp[len(sym):len(p.exp)]
+	if p.accept(sym) {
 		return
 	}
-	p.error("Expected %v, got %v", []byte(sym), p.exp[:len(sym)])
+	p.error("Expected %q", sym)
 }
 
 // Parse parses the given expression and returns a pluralForm function.
-func (p *peParser) Parse(exp []byte) (retForm pluralForm, retErr error) {
+// Modulo by zero evaluates to zero.
+func (p *peParser) Parse(exp []byte) (pluralForm, error) {
+	node, err := p.parse(exp)
+	if err != nil {
+		return nil, err
+	}
+	return compilePE(node).pluralForm(), nil
+}
+
+// parse parses the given expression and returns its syntax tree.
+func (p *peParser) parse(exp []byte) (retNode *peNode, retErr error) {
 	defer func() {
 		if r := recover(); r != nil {
 			if err, ok := r.(pError); ok {
-				retForm = nil
+				retNode = nil
 				retErr = err
 				return
 			}
 			panic(r)
 		}
 	}()
+	if len(exp) > peMaxLength {
+		return nil, pError{fmt.Sprintf(
+			"Expression exceeds maximum length of %d", peMaxLength), nil}
+	}
 	p.exp = exp
 	p.sym = []byte("")
-	pF := p.pExpression()
+	p.depth = 0
+	node := p.pExpression()
 	p.dewhitespace()
 	if len(p.exp) != 0 {
 		p.error("Trailing characters")
 	}
-	return pF, nil
+	return node, nil
 }
 
 // pExpression tries to parse an expression.
-func (p *peParser) pExpression() pluralForm {
+func (p *peParser) pExpression() *peNode {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > peMaxDepth {
+		p.error("Expression exceeds maximum depth of %d", peMaxDepth)
+	}
 	pri := p.pOred()
 	if p.accept("?") {
 		sec := p.pExpression()
 		p.expect(":")
 		ter := p.pExpression()
-		return func(n int) int {
-			if pri(n) > 0 {
-				return sec(n)
-			} else {
-				return ter(n)
-			}
-		}
+		return &peNode{op: peCond, args: []*peNode{pri, sec, ter}}
 	}
 	return pri
 }
 
 // pOred tries to parse an ored expression.
-func (p *peParser) pOred() pluralForm {
+func (p *peParser) pOred() *peNode {
 	fst := p.pAnded()
-	if p.accept("||") {
-		snd := p.pAnded()
-		return func(n int) int {
-			if fst(n) > 0 || snd(n) > 0 {
-				return 1
-			}
-			return 0
-		}
+	for p.accept("||") {
+		fst = &peNode{op: peOr, args: []*peNode{fst, p.pAnded()}}
 	}
 	return fst
 }
 
 // pAnded tries to parse an anded expression.
-func (p *peParser) pAnded() pluralForm {
+func (p *peParser) pAnded() *peNode {
 	fst := p.pEquality()
-	if p.accept("&&") {
-		snd := p.pEquality()
-		return func(n int) int {
-			if fst(n) > 0 && snd(n) > 0 {
-				return 1
-			}
-			return 0
-		}
+	for p.accept("&&") {
+		fst = &peNode{op: peAnd, args: []*peNode{fst, p.pEquality()}}
 	}
 	return fst
 }
 
 // pEquality tries to parse an equality expression.
-func (p *peParser) pEquality() pluralForm {
+func (p *peParser) pEquality() *peNode {
 	fst := p.pInEquality()
 	if p.accept("==") || p.accept("!=") {
-		sym := p.sym
-		snd := p.pInEquality()
-		return func(n int) int {
-			if string(sym) == "==" {
-				if fst(n) == snd(n) {
-					return 1
-				}
-			} else if fst(n) != snd(n) {
-				return 1
-			}
-			return 0
+		op := peEq
+		if string(p.sym) == "!=" {
+			op = peNe
 		}
+		return &peNode{op: op, args: []*peNode{fst, p.pInEquality()}}
 	}
 	return fst
 }
 
 // pInEquality tries to parse an inequality expression.
-func (p *peParser) pInEquality() pluralForm {
+func (p *peParser) pInEquality() *peNode {
 	fst := p.pProduct()
 	if p.accept("<=") || p.accept(">=") || p.accept(">") || p.accept("<") {
-		sym := p.sym
-		snd := p.pProduct()
-		return func(n int) int {
-			switch string(sym) {
-			case ">=":
-				if fst(n) >= snd(n) {
-					return 1
-				}
-			case "<=":
-				if fst(n) <= snd(n) {
-					return 1
-				}
-			case ">":
-				if fst(n) > snd(n) {
-					return 1
-				}
-			case "<":
-				if fst(n) < snd(n) {
-					return 1
-				}
-			}
-			return 0
+		var op peOp
+		switch string(p.sym) {
+		case ">=":
+			op = peGe
+		case "<=":
+			op = peLe
+		case ">":
+			op = peGt
+		case "<":
+			op = peLt
 		}
+		return &peNode{op: op, args: []*peNode{fst, p.pProduct()}}
 	}
 	return fst
 }
 
 // pProduct tries to parse a product expression.
-func (p *peParser) pProduct() pluralForm {
+func (p *peParser) pProduct() *peNode {
 	fst := p.pFactor()
 	if p.accept("%") {
-		snd := p.pFactor()
-		return func(n int) int {
-			return fst(n) % snd(n)
-		}
+		return &peNode{op: peMod, args: []*peNode{fst, p.pFactor()}}
 	}
 	return fst
 }
 
 // pFactor tries to parse a factor expression.
-func (p *peParser) pFactor() pluralForm {
+func (p *peParser) pFactor() *peNode {
 	switch {
 	case p.accept("n"):
-		return func(n int) int { return n }
+		return &peNode{op: peN}
 	case p.accept("("):
 		exp := p.pExpression()
 		p.expect(")")
@@ -228,7 +243,7 @@ func (p *peParser) pFactor() pluralForm {
 }
 
 // pNumber tries to parse a number expression.
-func (p *peParser) pNumber() pluralForm {
+func (p *peParser) pNumber() *peNode {
 	number := make([]byte, 0)
 	any := true
 	for any {
@@ -242,11 +257,74 @@ func (p *peParser) pNumber() pluralForm {
 			}
 		}
 	}
-	r, err := strconv.Atoi(string(number))
+	r, err := strconv.ParseUint(string(number), 10, 64)
 	if err != nil {
 		p.error("Could not parse number %q: %v", number, err)
 	}
-	return func(n int) int {
-		return r
+	return &peNode{op: peConst, value: r}
+}
+
+// peSymbols are the symbols of the binary operators.
+var peSymbols = map[peOp]string{
+	peMod: "%", peEq: "==", peNe: "!=", peLt: "<", peLe: "<=", peGt: ">",
+	peGe: ">=", peAnd: "&&", peOr: "||",
+}
+
+// precedence returns the precedence of the node's operator. Higher values bind
+// stronger.
+func (node *peNode) precedence() int {
+	switch node.op {
+	case peCond:
+		return 0
+	case peOr:
+		return 1
+	case peAnd:
+		return 2
+	case peEq, peNe:
+		return 3
+	case peLt, peLe, peGt, peGe:
+		return 4
+	case peMod:
+		return 5
+	}
+	return 6
+}
+
+// String returns the expression of the syntax tree with minimal parentheses.
+func (node *peNode) String() string {
+	var buffer bytes.Buffer
+	node.write(&buffer, 0)
+	return buffer.String()
+}
+
+// write writes the expression to the buffer, enclosed in parentheses if its
+// precedence is lower than the given one.
+func (node *peNode) write(buffer *bytes.Buffer, precedence int) {
+	own := node.precedence()
+	if own < precedence {
+		buffer.WriteString("(")
+		defer buffer.WriteString(")")
+	}
+	switch node.op {
+	case peN:
+		buffer.WriteString("n")
+	case peConst:
+		buffer.WriteString(strconv.FormatUint(node.value, 10))
+	case peCond:
+		node.args[0].write(buffer, own+1)
+		buffer.WriteString(" ? ")
+		node.args[1].write(buffer, own)
+		buffer.WriteString(" : ")
+		node.args[2].write(buffer, own)
+	case peAnd, peOr:
+		// Chains are left associative.
+		node.args[0].write(buffer, own)
+		buffer.WriteString(" " + peSymbols[node.op] + " ")
+		node.args[1].write(buffer, own+1)
+	default:
+		// Other operators can not be chained.
+		node.args[0].write(buffer, own+1)
+		buffer.WriteString(" " + peSymbols[node.op] + " ")
+		node.args[1].write(buffer, own+1)
 	}
 }
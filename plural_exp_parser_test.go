@@ -17,6 +17,7 @@
 package gettext
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -24,25 +25,27 @@ func TestParse(t *testing.T) {
 	parser := peParser{}
 	tests := []struct {
 		exp string
-		n   []int
-		ret []int
+		n   []uint64
+		ret []uint64
 	}{
-		{"0 ", []int{0, 1, 2}, []int{0, 0, 0}},
-		{"  n ", []int{0, 1, 2}, []int{0, 1, 2}},
-		{"n %  10", []int{2, 11, 32}, []int{2, 1, 2}},
-		{"532 % n", []int{1, 10, 100, 1000}, []int{0, 2, 32, 532}},
-		{"(n % (( 10 % 4 )))", []int{2, 11, 32}, []int{0, 1, 0}},
-		{"11 == n", []int{2, 11, 32}, []int{0, 1, 0}},
-		{"n != 11", []int{2, 11, 32}, []int{1, 0, 1}},
-		{"n >= 11", []int{1, 11, 12}, []int{0, 1, 1}},
-		{"n <= 11", []int{1, 11, 12}, []int{1, 1, 0}},
-		{"n > 11", []int{1, 11, 12}, []int{0, 0, 1}},
-		{"n < 11", []int{1, 11, 12}, []int{1, 0, 0}},
-		{"n && 1", []int{1, 0, 12}, []int{1, 0, 1}},
-		{"n || 1", []int{1, 0, 12}, []int{1, 1, 1}},
-		{"0 || n", []int{1, 0, 12}, []int{1, 0, 1}},
-		{"n ? 1 : 2", []int{1, 0}, []int{1, 2}},
-		{"n ? 0 ? 1 : 3 : 2", []int{1, 0}, []int{3, 2}},
+		{"0 ", []uint64{0, 1, 2}, []uint64{0, 0, 0}},
+		{"  n ", []uint64{0, 1, 2}, []uint64{0, 1, 2}},
+		{"n %  10", []uint64{2, 11, 32}, []uint64{2, 1, 2}},
+		{"532 % n", []uint64{1, 10, 100, 1000}, []uint64{0, 2, 32, 532}},
+		{"(n % (( 10 % 4 )))", []uint64{2, 11, 32}, []uint64{0, 1, 0}},
+		{"11 == n", []uint64{2, 11, 32}, []uint64{0, 1, 0}},
+		{"n != 11", []uint64{2, 11, 32}, []uint64{1, 0, 1}},
+		{"n >= 11", []uint64{1, 11, 12}, []uint64{0, 1, 1}},
+		{"n <= 11", []uint64{1, 11, 12}, []uint64{1, 1, 0}},
+		{"n > 11", []uint64{1, 11, 12}, []uint64{0, 0, 1}},
+		{"n < 11", []uint64{1, 11, 12}, []uint64{1, 0, 0}},
+		{"n && 1", []uint64{1, 0, 12}, []uint64{1, 0, 1}},
+		{"n || 1", []uint64{1, 0, 12}, []uint64{1, 1, 1}},
+		{"0 || n", []uint64{1, 0, 12}, []uint64{1, 0, 1}},
+		{"n ? 1 : 2", []uint64{1, 0}, []uint64{1, 2}},
+		{"n ? 0 ? 1 : 3 : 2", []uint64{1, 0}, []uint64{3, 2}},
+		{"n > 1 && n < 5 && n != 3", []uint64{1, 2, 3, 4}, []uint64{0, 1, 0, 1}},
+		{"n == 1 || n == 3 || n == 5", []uint64{1, 2, 3, 5}, []uint64{1, 0, 1, 1}},
 	}
 	for _, test := range tests {
 		pF, err := parser.Parse([]byte(test.exp))
@@ -59,3 +62,52 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestParseErrors(t *testing.T) {
+	parser := peParser{}
+	tests := []string{
+		"", "(n", "n ?", "n ? 1", "n ? 1 :", "n %", "n ==", "x", "n n",
+		"99999999999999999999",
+		strings.Repeat("(", peMaxDepth) + "n" + strings.Repeat(")", peMaxDepth),
+		strings.Repeat("n ? ", peMaxDepth) + "1" + strings.Repeat(" : 0", peMaxDepth),
+		strings.Repeat(" ", peMaxLength) + "n",
+	}
+	for _, exp := range tests {
+		if _, err := parser.Parse([]byte(exp)); err == nil {
+			t.Errorf("Parsing %.20q should fail", exp)
+		}
+	}
+	nested := strings.Repeat("(", peMaxDepth-1) + "n" +
+		strings.Repeat(")", peMaxDepth-1)
+	if _, err := parser.Parse([]byte(nested)); err != nil {
+		t.Errorf("Could not parse nested expression: %v", err)
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	parser := peParser{}
+	pF, err := parser.Parse([]byte("n % (n > 5)"))
+	if err != nil {
+		t.Fatalf("Could not parse: %v", err)
+	}
+	for n, ret := range map[uint64]uint64{0: 0, 5: 0, 6: 0, 1 << 40: 0} {
+		if pF(n) != ret {
+			t.Errorf("n %% (n > 5) with n = %v should be %v, got %v", n, ret,
+				pF(n))
+		}
+	}
+	for _, header := range []string{
+		"nplurals=2; plural=n % 0;",
+		"nplurals=2; plural=n % (n > 5);",
+		"nplurals=2; plural=5 % (n < 1000000);",
+		"nplurals=2; plural=n > 1000000 ? 5 % (n > 1048576) : 0;",
+	} {
+		if _, err := ParsePluralForms(header); err == nil {
+			t.Errorf("ParsePluralForms(%q) should fail", header)
+		}
+	}
+	if _, err := ParsePluralForms(
+		"nplurals=2; plural=n != 0 && 10 % n == 0;"); err != nil {
+		t.Errorf("Short circuited division should not fail: %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pluralFormsTable maps languages to their standard plural forms as used in
+// the Plural-Forms header of gettext catalogs.
+var pluralFormsTable = map[string]string{
+	// One form
+	"id": "nplurals=1; plural=0;",
+	"ja": "nplurals=1; plural=0;",
+	"km": "nplurals=1; plural=0;",
+	"ko": "nplurals=1; plural=0;",
+	"lo": "nplurals=1; plural=0;",
+	"ms": "nplurals=1; plural=0;",
+	"my": "nplurals=1; plural=0;",
+	"th": "nplurals=1; plural=0;",
+	"vi": "nplurals=1; plural=0;",
+	"zh": "nplurals=1; plural=0;",
+	// Two forms, singular used for one only
+	"af": "nplurals=2; plural=(n != 1);",
+	"bg": "nplurals=2; plural=(n != 1);",
+	"ca": "nplurals=2; plural=(n != 1);",
+	"da": "nplurals=2; plural=(n != 1);",
+	"de": "nplurals=2; plural=(n != 1);",
+	"el": "nplurals=2; plural=(n != 1);",
+	"en": "nplurals=2; plural=(n != 1);",
+	"eo": "nplurals=2; plural=(n != 1);",
+	"es": "nplurals=2; plural=(n != 1);",
+	"et": "nplurals=2; plural=(n != 1);",
+	"eu": "nplurals=2; plural=(n != 1);",
+	"fi": "nplurals=2; plural=(n != 1);",
+	"fo": "nplurals=2; plural=(n != 1);",
+	"gl": "nplurals=2; plural=(n != 1);",
+	"he": "nplurals=2; plural=(n != 1);",
+	"hu": "nplurals=2; plural=(n != 1);",
+	"it": "nplurals=2; plural=(n != 1);",
+	"nb": "nplurals=2; plural=(n != 1);",
+	"nl": "nplurals=2; plural=(n != 1);",
+	"nn": "nplurals=2; plural=(n != 1);",
+	"no": "nplurals=2; plural=(n != 1);",
+	"pt": "nplurals=2; plural=(n != 1);",
+	"sq": "nplurals=2; plural=(n != 1);",
+	"sv": "nplurals=2; plural=(n != 1);",
+	"tr": "nplurals=2; plural=(n != 1);",
+	// Two forms, singular used for zero and one
+	"fr":    "nplurals=2; plural=(n > 1);",
+	"oc":    "nplurals=2; plural=(n > 1);",
+	"pt_BR": "nplurals=2; plural=(n > 1);",
+	// Two forms, special cases for numbers ending in one
+	"is": "nplurals=2; plural=(n%10 != 1 || n%100 == 11);",
+	"mk": "nplurals=2; plural=(n == 1 || n%10 == 1 ? 0 : 1);",
+	// Three forms, special case for zero
+	"lv": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n != 0 ? 1 : 2);",
+	// Three forms, special cases for numbers ending in one and two to four
+	"be": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : " +
+		"n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"bs": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : " +
+		"n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"hr": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : " +
+		"n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"ru": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : " +
+		"n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"sr": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : " +
+		"n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"uk": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : " +
+		"n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	// Three forms, special cases for numbers ending in one and two to nine
+	"lt": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : " +
+		"n%10 >= 2 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	// Three forms, special cases for one and some numbers ending in two to four
+	"pl": "nplurals=3; plural=(n == 1 ? 0 : " +
+		"n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	// Three forms, special cases for one and two to four
+	"cs": "nplurals=3; plural=(n == 1 ? 0 : n >= 2 && n <= 4 ? 1 : 2);",
+	"sk": "nplurals=3; plural=(n == 1 ? 0 : n >= 2 && n <= 4 ? 1 : 2);",
+	// Three forms, special cases for one and zero and numbers ending in 01-19
+	"ro": "nplurals=3; plural=(n == 1 ? 0 : " +
+		"(n == 0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2);",
+	// Four forms, special cases for numbers ending in 01, 02, 03 and 04
+	"sl": "nplurals=4; plural=(n%100 == 1 ? 0 : n%100 == 2 ? 1 : " +
+		"n%100 == 3 || n%100 == 4 ? 2 : 3);",
+	// Four forms, special cases for one, two, eight and eleven
+	"cy": "nplurals=4; plural=(n == 1 ? 0 : n == 2 ? 1 : " +
+		"n != 8 && n != 11 ? 2 : 3);",
+	// Five forms, special cases for one, two, three to six and seven to ten
+	"ga": "nplurals=5; plural=(n == 1 ? 0 : n == 2 ? 1 : n > 2 && n < 7 ? 2 : " +
+		"n > 6 && n < 11 ? 3 : 4);",
+	// Six forms, special cases for zero, one, two, and numbers ending in 03-10
+	// and 11-99
+	"ar": "nplurals=6; plural=(n == 0 ? 0 : n == 1 ? 1 : n == 2 ? 2 : " +
+		"n%100 >= 3 && n%100 <= 10 ? 3 : n%100 >= 11 ? 4 : 5);",
+}
+
+// builtinPluralForms returns the standard plural forms for the given language
+// or locale, or an empty string if it is unknown.
+func builtinPluralForms(locale string) string {
+	parsed, err := ParseLocale(locale)
+	if err != nil {
+		return ""
+	}
+	if len(parsed.Territory) > 0 {
+		if forms, ok := pluralFormsTable[parsed.Language+"_"+
+			parsed.Territory]; ok {
+			return forms
+		}
+	}
+	return pluralFormsTable[parsed.Language]
+}
+
+// PluralRule is a parsed Plural-Forms header of a gettext catalog.
+type PluralRule struct {
+	// NPlurals is the number of plural forms.
+	NPlurals int
+	// Expression is the normalized plural expression with minimal
+	// parentheses and single spaces around operators.
+	Expression string
+	pf         pluralForm
+}
+
+// germanicPluralRule is used for catalogs without known plural forms.
+var germanicPluralRule, _ = ParsePluralForms("nplurals=2; plural=n != 1;")
+
+// ParsePluralForms parses the value of a Plural-Forms header like
+// "nplurals=2; plural=(n != 1);".
+//
+// It fails if the expression can not be parsed or divides by zero for any n
+// in [0, 1000) or some larger samples.
+func ParsePluralForms(header string) (PluralRule, error) {
+	var rule PluralRule
+	var exp string
+	for _, field := range strings.Split(header, ";") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(parts[0]) {
+		case "nplurals":
+			var err error
+			rule.NPlurals, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil || rule.NPlurals < 1 {
+				return PluralRule{}, fmt.Errorf(
+					"Invalid number of plural forms %q", parts[1])
+			}
+		case "plural":
+			exp = parts[1]
+		}
+	}
+	if rule.NPlurals == 0 {
+		return PluralRule{}, fmt.Errorf(
+			"Missing number of plural forms in %q", header)
+	}
+	if len(strings.TrimSpace(exp)) == 0 {
+		return PluralRule{}, fmt.Errorf("Missing plural expression in %q",
+			header)
+	}
+	var parser peParser
+	node, err := parser.parse([]byte(exp))
+	if err != nil {
+		return PluralRule{}, err
+	}
+	program := compilePE(node)
+	if err := program.check(); err != nil {
+		return PluralRule{}, err
+	}
+	rule.Expression = node.String()
+	rule.pf = program.pluralForm()
+	return rule, nil
+}
+
+// Index returns the index of the plural form for n. The index may be out of
+// range if the expression is faulty.
+func (r PluralRule) Index(n uint64) int {
+	if r.pf == nil {
+		return 0
+	}
+	index := r.pf(n)
+	if index > uint64(maxInt) {
+		return maxInt
+	}
+	return int(index)
+}
+
+// maxInt is the largest int.
+const maxInt = int(^uint(0) >> 1)
+
+// String returns the normalized Plural-Forms header of the rule.
+func (r PluralRule) String() string {
+	return fmt.Sprintf("nplurals=%d; plural=%s;", r.NPlurals, r.Expression)
+}
+
+// checkPluralForms compares the given plural rule with the standard one of
+// the given language for n in [0, 1000) and returns an error if they
+// disagree.
+func checkPluralForms(language string, rule PluralRule) error {
+	builtin := builtinPluralForms(language)
+	if len(builtin) == 0 {
+		return nil
+	}
+	std, err := ParsePluralForms(builtin)
+	if err != nil {
+		return err
+	}
+	if rule.NPlurals != std.NPlurals {
+		return fmt.Errorf("Catalog has %d plural forms, but %q has %d",
+			rule.NPlurals, language, std.NPlurals)
+	}
+	for n := uint64(0); n < 1000; n++ {
+		if rule.pf(n) != std.pf(n) {
+			return fmt.Errorf("Plural form for n = %d is %d, but %d for %q",
+				n, rule.pf(n), std.pf(n), language)
+		}
+	}
+	return nil
+}
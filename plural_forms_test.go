@@ -0,0 +1,158 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestPluralFormsTable(t *testing.T) {
+	for language, forms := range pluralFormsTable {
+		rule, err := ParsePluralForms(forms)
+		if err != nil {
+			t.Errorf("Could not parse plural forms of %q: %v", language, err)
+			continue
+		}
+		for n := uint64(0); n < 1000; n++ {
+			if i := rule.Index(n); i >= rule.NPlurals {
+				t.Errorf("Plural form of %q for n = %d is out of range: %d",
+					language, n, i)
+				break
+			}
+		}
+	}
+	tests := []struct {
+		locale string
+		n      []uint64
+		forms  []int
+	}{
+		{"ja_JP", []uint64{0, 1, 2}, []int{0, 0, 0}},
+		{"de-AT", []uint64{0, 1, 2}, []int{1, 0, 1}},
+		{"fr", []uint64{0, 1, 2}, []int{0, 0, 1}},
+		{"pt_BR.UTF-8", []uint64{0, 1, 2}, []int{0, 0, 1}},
+		{"pt_PT", []uint64{0, 1, 2}, []int{1, 0, 1}},
+		{"ru", []uint64{1, 2, 5, 11, 21, 22, 111}, []int{0, 1, 2, 2, 0, 1, 2}},
+		{"pl", []uint64{1, 2, 5, 12, 21, 22}, []int{0, 1, 2, 2, 2, 1}},
+		{"ar", []uint64{0, 1, 2, 3, 11, 100, 102}, []int{0, 1, 2, 3, 4, 5, 5}},
+	}
+	for _, test := range tests {
+		rule, err := ParsePluralForms(builtinPluralForms(test.locale))
+		if err != nil {
+			t.Errorf("Could not get plural forms of %q: %v", test.locale, err)
+			continue
+		}
+		for i, n := range test.n {
+			if ret := rule.Index(n); ret != test.forms[i] {
+				t.Errorf("Plural form of %q for n = %d should be %d, got %d",
+					test.locale, n, test.forms[i], ret)
+			}
+		}
+	}
+	if forms := builtinPluralForms("xx"); forms != "" {
+		t.Errorf("There should be no plural forms for xx, got %q", forms)
+	}
+}
+
+func TestParsePluralForms(t *testing.T) {
+	tests := []struct {
+		header   string
+		nPlurals int
+		ok       bool
+	}{
+		{"nplurals=2; plural=n != 1;", 2, true},
+		{" nplurals = 3 ; plural = (n == 1 ? 0 : n == 2 ? 1 : 2)", 3, true},
+		{"nplurals=2;", 0, false},
+		{"plural=n != 1;", 0, false},
+		{"nplurals=x; plural=n != 1;", 0, false},
+		{"nplurals=2; plural=n !! 1;", 0, false},
+	}
+	for _, test := range tests {
+		rule, err := ParsePluralForms(test.header)
+		if (err == nil) != test.ok || rule.NPlurals != test.nPlurals {
+			t.Errorf("ParsePluralForms(%q) should return %d (ok: %v), got %d (%v)",
+				test.header, test.nPlurals, test.ok, rule.NPlurals, err)
+		}
+	}
+}
+
+func TestPluralFormsFallback(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pl/LC_MESSAGES/test.mo": {Data: makeMO(map[string]string{
+			"":              "Language: pl\n",
+			"File\x00Files": "Plik\x00Pliki\x00Plików",
+		})},
+	}
+	locales := Locales{FS: fsys}
+	_, GN, _, _ := locales.Use("test", "pl")
+	for n, translated := range map[int]string{1: "Plik", 3: "Pliki",
+		5: "Plików"} {
+		if ret := GN("File", "Files", n); ret != translated {
+			t.Errorf("Translation for n = %d should be %q, got %q", n,
+				translated, ret)
+		}
+	}
+	if warnings := locales.Warnings("test", "pl"); len(warnings) != 0 {
+		t.Errorf("There should be no warnings, got %v", warnings)
+	}
+	locales = Locales{LocaleDir: setupLocales(t).LocaleDir}
+	locales.Use("test", "de")
+	if warnings := locales.Warnings("test", "de"); len(warnings) != 1 {
+		t.Errorf("There should be one warning for the test catalog, got %v",
+			warnings)
+	}
+}
+
+func TestPluralRuleExpression(t *testing.T) {
+	tests := []struct {
+		exp, normalized string
+	}{
+		{"(n != 1)", "n != 1"},
+		{"n==1 ? 0 : n==3 ? 2 : 1", "n == 1 ? 0 : n == 3 ? 2 : 1"},
+		{"(n==1?0:1)?(n%10):2", "(n == 1 ? 0 : 1) ? n % 10 : 2"},
+		{"n%(10%4)", "n % (10 % 4)"},
+		{"(n>1)==(n<5)", "n > 1 == n < 5"},
+		{"(n==1)==(n!=5)", "(n == 1) == (n != 5)"},
+		{"(n>1 || n<5) && (n || 0)", "(n > 1 || n < 5) && (n || 0)"},
+		{"n>1 || (n<5 || n) || (n && 1)", "n > 1 || (n < 5 || n) || n && 1"},
+	}
+	for _, test := range tests {
+		rule, err := ParsePluralForms("nplurals=3; plural=" + test.exp + ";")
+		if err != nil {
+			t.Errorf("Could not parse %q: %v", test.exp, err)
+			continue
+		}
+		if rule.Expression != test.normalized {
+			t.Errorf("%q should be normalized to %q, got %q", test.exp,
+				test.normalized, rule.Expression)
+		}
+		reparsed, err := ParsePluralForms(rule.String())
+		if err != nil || reparsed.Expression != rule.Expression {
+			t.Errorf("Could not reparse %q: %v", rule.String(), err)
+			continue
+		}
+		for n := uint64(0); n < 20; n++ {
+			if rule.Index(n) != reparsed.Index(n) {
+				t.Errorf("Reparsed %q differs for n = %d", rule.String(), n)
+			}
+		}
+	}
+	var rule PluralRule
+	if rule.Index(5) != 0 {
+		t.Errorf("Zero PluralRule should return index zero")
+	}
+}
@@ -0,0 +1,114 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// poEntry is an entry of a PO or POT file.
+type poEntry struct {
+	Context, ID, Plural string
+	// HasContext is true iff the entry has a msgctxt keyword.
+	HasContext   bool
+	Translations []string
+}
+
+// key returns the key of the entry's message as used in MO files.
+func (e *poEntry) key() message {
+	if e.HasContext {
+		return message{e.Context + contextSeparator + e.ID, e.Plural}
+	}
+	return message{e.ID, e.Plural}
+}
+
+// parsePO parses a PO or POT file. Comments and obsolete entries are skipped.
+func parsePO(r io.Reader) ([]poEntry, error) {
+	var entries []poEntry
+	var entry *poEntry
+	// target points to the string the last keyword refers to
+	var target *string
+	// contextOnly is true iff the current entry has no msgid yet
+	var contextOnly bool
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		error := func(msg string, args ...interface{}) error {
+			return fmt.Errorf("line %d: %v", lineNo, fmt.Sprintf(msg, args...))
+		}
+		if line[0] == '"' {
+			if target == nil {
+				return nil, error("Unexpected string")
+			}
+			value, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, error("Could not parse string: %v", err)
+			}
+			*target += value
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, error("Missing string")
+		}
+		keyword := fields[0]
+		value, err := strconv.Unquote(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return nil, error("Could not parse string: %v", err)
+		}
+		if keyword == "msgctxt" || keyword == "msgid" && !contextOnly {
+			entries = append(entries, poEntry{})
+			entry = &entries[len(entries)-1]
+		} else if entry == nil {
+			return nil, error("Expected msgctxt or msgid")
+		}
+		contextOnly = keyword == "msgctxt"
+		switch {
+		case keyword == "msgctxt":
+			entry.HasContext = true
+			target = &entry.Context
+		case keyword == "msgid":
+			target = &entry.ID
+		case keyword == "msgid_plural":
+			target = &entry.Plural
+		case keyword == "msgstr":
+			entry.Translations = append(entry.Translations, "")
+			target = &entry.Translations[len(entry.Translations)-1]
+		case strings.HasPrefix(keyword, "msgstr["):
+			index, err := strconv.Atoi(strings.TrimSuffix(keyword[7:], "]"))
+			if err != nil || index != len(entry.Translations) {
+				return nil, error("Invalid keyword %q", keyword)
+			}
+			entry.Translations = append(entry.Translations, "")
+			target = &entry.Translations[len(entry.Translations)-1]
+		default:
+			return nil, error("Unknown keyword %q", keyword)
+		}
+		*target = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
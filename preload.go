@@ -0,0 +1,103 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"sync"
+)
+
+// LoadError is an error loading the message catalog of a domain and locale.
+type LoadError struct {
+	Domain, Locale string
+	Err            error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("Could not load catalog for domain %q and locale %q: %v",
+		e.Domain, e.Locale, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// exists returns true if there is a message catalog for the given domain and
+// locale, either in the file system or installed with Add.
+func (l *Locales) exists(domain, locale string) bool {
+	l.mutex.RLock()
+	_, ok := l.translations[domain][localeKey(locale)]
+	l.mutex.RUnlock()
+	if ok || isPseudoLocale(locale) {
+		return true
+	}
+	_, err := l.findCatalog(domain, locale, func(fsys fs.FS,
+		name string) error {
+		_, err := fs.Stat(fsys, name)
+		return err
+	})
+	return err == nil
+}
+
+// Preload loads the message catalogs for all combinations of the given
+// domains and locales in parallel, e.g. to fail fast at startup. Missing
+// catalogs are skipped unless mustExist is set.
+//
+// The returned error joins a *LoadError for each catalog which could not be
+// loaded, in the order of the given domains and locales.
+func (l *Locales) Preload(domains, locales []string, mustExist bool) error {
+	type job struct {
+		domain, locale string
+	}
+	jobs := make([]job, 0, len(domains)*len(locales))
+	for _, domain := range domains {
+		for _, locale := range locales {
+			jobs = append(jobs, job{domain, locale})
+		}
+	}
+	errs := make([]error, len(jobs))
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				domain, locale := jobs[i].domain, jobs[i].locale
+				if !mustExist && !l.exists(domain, locale) {
+					continue
+				}
+				if _, err := l.get(domain, locale); err != nil {
+					errs[i] = &LoadError{domain, locale, err}
+				}
+			}
+		}()
+	}
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return errors.Join(errs...)
+}
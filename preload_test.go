@@ -0,0 +1,69 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPreload(t *testing.T) {
+	fsys := fstest.MapFS{
+		"de/LC_MESSAGES/a.mo": &fstest.MapFile{
+			Data: makeMO(map[string]string{"Message": "a Message"})},
+		"fr/LC_MESSAGES/a.mo": &fstest.MapFile{
+			Data: makeMO(map[string]string{"Message": "a Message"})},
+		"de/LC_MESSAGES/b.mo": &fstest.MapFile{Data: []byte("broken")},
+	}
+	locales := Locales{FS: fsys}
+	err := locales.Preload([]string{"a", "b"}, []string{"de", "fr"}, false)
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("Preload should fail with a *LoadError, got %v", err)
+	}
+	if loadErr.Domain != "b" || loadErr.Locale != "de" {
+		t.Errorf("Unexpected failed catalog: %v", loadErr)
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok ||
+		len(joined.Unwrap()) != 1 {
+		t.Errorf("Preload should fail for exactly one catalog, got %v", err)
+	}
+	if stats := locales.CacheStats(); stats.Catalogs != 2 {
+		t.Errorf("Two catalogs should have been loaded, got %d",
+			stats.Catalogs)
+	}
+	if ret := locales.Singular("a", "fr", "Message"); ret != "a Message" {
+		t.Errorf(`Translation should be "a Message", got %q`, ret)
+	}
+
+	err = locales.Preload([]string{"a", "b"}, []string{"de", "fr"}, true)
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok ||
+		len(joined.Unwrap()) != 2 {
+		t.Fatalf("Preload should fail for two catalogs, got %v", err)
+	}
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if !errors.As(errs[1], &loadErr) || loadErr.Domain != "b" ||
+		loadErr.Locale != "fr" {
+		t.Errorf("Second error should be for b/fr, got %v", errs[1])
+	}
+
+	if err := locales.Preload([]string{"a"}, []string{"de", "en",
+		PseudoLocale}, false); err != nil {
+		t.Errorf("Preload should succeed, got %v", err)
+	}
+}
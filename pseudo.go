@@ -0,0 +1,88 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// PseudoLocale is a locale for which Locales synthesizes pseudo translations
+// of all messages instead of loading message catalogs. See Pseudolocalize.
+const PseudoLocale = "qps-ploc"
+
+// pseudoAccents maps ASCII letters to accented look-alikes.
+var pseudoAccents = map[rune]rune{
+	'a': 'á', 'b': 'ƀ', 'c': 'ç', 'd': 'ð', 'e': 'é', 'f': 'ƒ', 'g': 'ĝ',
+	'h': 'ĥ', 'i': 'í', 'j': 'ĵ', 'k': 'ķ', 'l': 'ļ', 'm': 'ɱ', 'n': 'ñ',
+	'o': 'ó', 'p': 'þ', 'q': 'ǫ', 'r': 'ŕ', 's': 'š', 't': 'ŧ', 'u': 'ú',
+	'v': 'ṽ', 'w': 'ŵ', 'x': 'ẋ', 'y': 'ý', 'z': 'ž',
+	'A': 'Å', 'B': 'Ɓ', 'C': 'Ç', 'D': 'Đ', 'E': 'É', 'F': 'Ƒ', 'G': 'Ĝ',
+	'H': 'Ĥ', 'I': 'Î', 'J': 'Ĵ', 'K': 'Ķ', 'L': 'Ļ', 'M': 'Ṁ', 'N': 'Ñ',
+	'O': 'Ö', 'P': 'Þ', 'Q': 'Ǫ', 'R': 'Ŕ', 'S': 'Š', 'T': 'Ŧ', 'U': 'Û',
+	'V': 'Ṽ', 'W': 'Ŵ', 'X': 'Ẋ', 'Y': 'Ý', 'Z': 'Ž',
+}
+
+// pseudoPreserved matches parts of messages which must not be changed: fmt
+// verbs, named placeholders, escaped braces, HTML tags and entities.
+var pseudoPreserved = regexp.MustCompile(
+	`%(\[\d+\])?[-+# 0]*(\*|\d+)?(\.(\*|\d+)?)?(\[\d+\])?[a-zA-Z%]|` +
+		`\{\{|\}\}|\{\w+\}|<[^<>]*>|&#?\w+;`)
+
+// Pseudolocalize returns a pseudo translation of the given message: Letters
+// are replaced by accented ones, the message is enclosed in brackets and
+// expanded by about a third to catch truncation. fmt verbs, named
+// placeholders, HTML tags and entities are preserved.
+//
+// Empty messages are returned unchanged.
+func Pseudolocalize(msg string) string {
+	if len(msg) == 0 {
+		return msg
+	}
+	var buffer strings.Builder
+	var letters int
+	accent := func(text string) {
+		for _, c := range text {
+			if accented, ok := pseudoAccents[c]; ok {
+				c = accented
+			}
+			buffer.WriteRune(c)
+		}
+		letters += utf8.RuneCountInString(text)
+	}
+	buffer.WriteString("[")
+	last := 0
+	for _, match := range pseudoPreserved.FindAllStringIndex(msg, -1) {
+		accent(msg[last:match[0]])
+		buffer.WriteString(msg[match[0]:match[1]])
+		last = match[1]
+	}
+	accent(msg[last:])
+	buffer.WriteString(" " + strings.Repeat("!", (letters+2)/3) + "]")
+	return buffer.String()
+}
+
+// newPseudoTranslation returns a translation synthesizing pseudo translations
+// of all messages.
+func newPseudoTranslation() *translation {
+	return &translation{
+		msgs:   make(map[message][][]byte),
+		plural: germanicPluralRule,
+		pseudo: true,
+	}
+}
@@ -0,0 +1,71 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPseudolocalize(t *testing.T) {
+	tests := []struct {
+		msg, pseudo string
+	}{
+		{"", ""},
+		{"Hello", "[Ĥéļļó !!]"},
+		{"%d files in %s", "[%d ƒíļéš íñ %s !!!!]"},
+		{"%[2]*.3f%% of %-5v", "[%[2]*.3f%% óƒ %-5v !!]"},
+		{"Hello {name}, {{x}}", "[Ĥéļļó {name}, {{ẋ}} !!!]"},
+		{`<a href="x">Link</a> &amp; more`,
+			`[<a href="x">Ļíñķ</a> &amp; ɱóŕé !!!!]`},
+	}
+	for _, test := range tests {
+		if ret := Pseudolocalize(test.msg); ret != test.pseudo {
+			t.Errorf("Pseudolocalize(%q) should return %q, got %q", test.msg,
+				test.pseudo, ret)
+		}
+	}
+	if ret := fmt.Sprintf(Pseudolocalize("%d of %s"), 3, "x"); ret !=
+		"[3 óƒ x !!]" {
+		t.Errorf("Pseudo localized verbs should be usable, got %q", ret)
+	}
+}
+
+func TestPseudoLocale(t *testing.T) {
+	var locales Locales
+	tr := locales.Translator("test", PseudoLocale)
+	tests := []struct {
+		ret, translated string
+	}{
+		{tr.Singular("File"), "[Ƒíļé !!]"},
+		{tr.Plural("File", "Files", 1), "[Ƒíļé !!]"},
+		{tr.Plural("File", "Files", 2), "[Ƒíļéš !!]"},
+		{tr.ContextSingular("Menu", "File"), "[Ƒíļé !!]"},
+		{tr.ContextPlural("Menu", "File", "Files", 0), "[Ƒíļéš !!]"},
+	}
+	for i, test := range tests {
+		if test.ret != test.translated {
+			t.Errorf("Test %v: Translation should be %q, got %q", i,
+				test.translated, test.ret)
+		}
+	}
+	if ret, err := tr.PluralDecimal("File", "Files", "1.5"); err != nil ||
+		ret != "[Ƒíļéš !!]" {
+		t.Errorf(`Translation of "1.5" should be "[Ƒíļéš !!]", got %q (%v)`,
+			ret, err)
+	}
+}
@@ -0,0 +1,134 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"io"
+)
+
+// Statistics describes the translation progress of a message catalog.
+type Statistics struct {
+	// Entries is the number of messages in the catalog, not counting the
+	// header entry.
+	Entries int
+	// Empty is the number of messages whose translations are all empty.
+	Empty int
+	// MissingPluralForms is the number of plural messages having less
+	// non-empty translations than the catalog's number of plural forms.
+	MissingPluralForms int
+	// Reference is the number of messages in the reference template, or zero
+	// if there is none.
+	Reference int
+	// Covered is the number of messages in the reference template having a
+	// complete translation in the catalog.
+	Covered int
+}
+
+// Translated returns the number of messages in the catalog having a complete
+// translation.
+func (s *Statistics) Translated() int {
+	return s.Entries - s.Empty - s.MissingPluralForms
+}
+
+// Coverage returns the fraction of messages in the reference template having
+// a complete translation in the catalog, between 0 and 1. Without a reference
+// template, it returns the fraction of complete translations in the catalog
+// like CatalogInfo.Completeness.
+func (s *Statistics) Coverage() float64 {
+	if s.Reference > 0 {
+		return fraction(s.Covered, s.Reference)
+	}
+	return fraction(s.Translated(), s.Entries)
+}
+
+// fraction returns part divided by whole, or 1 if whole is zero.
+func fraction(part, whole int) float64 {
+	if whole == 0 {
+		return 1
+	}
+	return float64(part) / float64(whole)
+}
+
+// complete returns true iff the given translations of a message are all
+// non-empty and cover all plural forms.
+func complete(msg message, translations [][]byte, nPlurals int) bool {
+	if len(msg.Plural) > 0 && len(translations) < nPlurals {
+		return false
+	}
+	for _, translation := range translations {
+		if len(translation) == 0 {
+			return false
+		}
+	}
+	return len(translations) > 0
+}
+
+// Statistics computes the translation progress of the message catalog for the
+// given domain and locale. If pot is not nil, the messages of the catalog are
+// compared to the ones of the given POT file to compute the coverage.
+func (l *Locales) Statistics(domain, locale string,
+	pot io.Reader) (*Statistics, error) {
+	tr, _, err := l.load(domain, locale)
+	if err != nil {
+		return nil, err
+	}
+	stats := tr.statistics()
+	if pot != nil {
+		entries, err := parsePO(pot)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if len(entry.ID) == 0 && !entry.HasContext {
+				continue
+			}
+			stats.Reference++
+			key := entry.key()
+			if complete(key, tr.msgs[key], tr.plural.NPlurals) {
+				stats.Covered++
+			}
+		}
+	}
+	return &stats, nil
+}
+
+// statistics counts the complete, empty and incomplete messages of the
+// translation.
+func (t *translation) statistics() Statistics {
+	var stats Statistics
+	for msg, translations := range t.msgs {
+		if len(msg.Singular) == 0 && len(msg.Plural) == 0 {
+			continue
+		}
+		stats.Entries++
+		empty := true
+		for _, translation := range translations {
+			if len(translation) > 0 {
+				empty = false
+			}
+		}
+		switch {
+		case empty:
+			stats.Empty++
+		case !complete(msg, translations, t.plural.NPlurals):
+			if len(msg.Plural) > 0 {
+				stats.MissingPluralForms++
+			}
+		}
+	}
+	return stats
+}
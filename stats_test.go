@@ -0,0 +1,109 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParsePO(t *testing.T) {
+	po := `# Comment
+msgid "A"
+msgstr "B"
+
+msgctxt "C"
+msgid ""
+"D\n"
+msgid_plural "E"
+msgstr[0] "F"
+msgstr[1] ""
+"G"
+`
+	entries, err := parsePO(strings.NewReader(po))
+	if err != nil {
+		t.Fatalf("Could not parse PO: %v", err)
+	}
+	expected := []poEntry{
+		{ID: "A", Translations: []string{"B"}},
+		{Context: "C", HasContext: true, ID: "D\n", Plural: "E",
+			Translations: []string{"F", "G"}},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("parsePO should return %v, got %v", expected, entries)
+	}
+	for _, po := range []string{`"A"`, `msgid A`, `msgid "A"
+msgstr[1] "B"`, `foo "A"`} {
+		if _, err := parsePO(strings.NewReader(po)); err == nil {
+			t.Errorf("parsePO(%q) should fail", po)
+		}
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	pot, err := os.Open("test_locale/test.pot")
+	if err != nil {
+		t.Fatalf("Could not open template: %v", err)
+	}
+	defer pot.Close()
+	stats, err := setupLocales(t).Statistics("test", "de", pot)
+	if err != nil {
+		t.Fatalf("Could not compute statistics: %v", err)
+	}
+	expected := Statistics{Entries: 4, Reference: 5, Covered: 4}
+	if *stats != expected {
+		t.Errorf("Statistics should be %+v, got %+v", expected, *stats)
+	}
+	if stats.Coverage() != 0.8 {
+		t.Errorf("Coverage should be 0.8, got %v", stats.Coverage())
+	}
+}
+
+func TestStatisticsIncomplete(t *testing.T) {
+	fsys := fstest.MapFS{
+		"de/LC_MESSAGES/app.mo": &fstest.MapFile{Data: makeMO(map[string]string{
+			"":                  "Plural-Forms: nplurals=2; plural=n != 1;\n",
+			"Message":           "Nachricht",
+			"Empty":             "",
+			"Menu\x04Open":      "Öffnen",
+			"File\x00Files":     "Datei\x00Dateien",
+			"Empty\x00Plural":   "\x00",
+			"Window\x00Windows": "Fenster",
+			"Tab\x00Tabs":       "\x00Tabs",
+		})},
+	}
+	locales := New(WithFS(fsys))
+	stats, err := locales.Statistics("app", "de", nil)
+	if err != nil {
+		t.Fatalf("Could not compute statistics: %v", err)
+	}
+	expected := Statistics{Entries: 7, Empty: 2, MissingPluralForms: 2}
+	if *stats != expected {
+		t.Errorf("Statistics should be %+v, got %+v", expected, *stats)
+	}
+	info, err := locales.CatalogInfo("app", "de")
+	if err != nil {
+		t.Fatalf("Could not get catalog info: %v", err)
+	}
+	if info.Entries != 7 || info.Translated != 3 ||
+		info.Completeness() != stats.Coverage() {
+		t.Errorf("Catalog info should agree with %+v, got %+v", *stats, *info)
+	}
+}
@@ -0,0 +1,124 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"fmt"
+	"html/template"
+)
+
+// FuncMap returns template functions translating messages with the given
+// translator, e.g. for html/template:
+//
+//   - gettext msgid
+//   - ngettext singular plural n
+//   - pgettext context msgid
+//   - npgettext context singular plural n
+//   - dgettext domain msgid
+//   - dngettext domain singular plural n
+//
+// Each function has a formatted variant with an "f" suffix, e.g. gettextf,
+// which formats the translation with the remaining arguments like
+// fmt.Sprintf.
+//
+// The functions return strings, so translations are escaped like any other
+// value by html/template. For messages containing trusted markup, use the
+// variants with an "HTML" suffix, e.g. gettextHTML, which return
+// template.HTML. They take optional arguments to format the translation
+// with, which are escaped unless they are of type template.HTML.
+//
+// The translator may be nil, in which case messages are not translated.
+func FuncMap(tr *Translator) template.FuncMap {
+	return template.FuncMap{
+		"gettext":   tr.Singular,
+		"ngettext":  tr.Plural,
+		"pgettext":  tr.ContextSingular,
+		"npgettext": tr.ContextPlural,
+		"dgettext":  tr.DomainSingular,
+		"dngettext": tr.DomainPlural,
+		"gettextf": func(msgid string, args ...interface{}) string {
+			return fmt.Sprintf(tr.Singular(msgid), args...)
+		},
+		"ngettextf": func(singular, plural string, n int,
+			args ...interface{}) string {
+			return fmt.Sprintf(tr.Plural(singular, plural, n), args...)
+		},
+		"pgettextf": func(context, msgid string, args ...interface{}) string {
+			return fmt.Sprintf(tr.ContextSingular(context, msgid), args...)
+		},
+		"npgettextf": func(context, singular, plural string, n int,
+			args ...interface{}) string {
+			return fmt.Sprintf(tr.ContextPlural(context, singular, plural, n),
+				args...)
+		},
+		"dgettextf": func(domain, msgid string, args ...interface{}) string {
+			return fmt.Sprintf(tr.DomainSingular(domain, msgid), args...)
+		},
+		"dngettextf": func(domain, singular, plural string, n int,
+			args ...interface{}) string {
+			return fmt.Sprintf(tr.DomainPlural(domain, singular, plural, n),
+				args...)
+		},
+		"gettextHTML": func(msgid string, args ...interface{}) template.HTML {
+			return formatHTML(tr.Singular(msgid), args)
+		},
+		"ngettextHTML": func(singular, plural string, n int,
+			args ...interface{}) template.HTML {
+			return formatHTML(tr.Plural(singular, plural, n), args)
+		},
+		"pgettextHTML": func(context, msgid string,
+			args ...interface{}) template.HTML {
+			return formatHTML(tr.ContextSingular(context, msgid), args)
+		},
+		"npgettextHTML": func(context, singular, plural string, n int,
+			args ...interface{}) template.HTML {
+			return formatHTML(tr.ContextPlural(context, singular, plural, n),
+				args)
+		},
+		"dgettextHTML": func(domain, msgid string,
+			args ...interface{}) template.HTML {
+			return formatHTML(tr.DomainSingular(domain, msgid), args)
+		},
+		"dngettextHTML": func(domain, singular, plural string, n int,
+			args ...interface{}) template.HTML {
+			return formatHTML(tr.DomainPlural(domain, singular, plural, n),
+				args)
+		},
+	}
+}
+
+// formatHTML returns the given trusted markup, formatted with the given
+// arguments like fmt.Sprintf if there are any. Arguments other than numbers
+// and booleans are escaped unless they are of type template.HTML.
+func formatHTML(markup string, args []interface{}) template.HTML {
+	if len(args) == 0 {
+		return template.HTML(markup)
+	}
+	escaped := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case template.HTML:
+			escaped[i] = string(arg)
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32,
+			uint64, float32, float64, bool:
+			escaped[i] = arg
+		default:
+			escaped[i] = template.HTMLEscapeString(fmt.Sprint(arg))
+		}
+	}
+	return template.HTML(fmt.Sprintf(markup, escaped...))
+}
@@ -0,0 +1,82 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+)
+
+func TestFuncMap(t *testing.T) {
+	locales := setupLocales(t)
+	catalog := NewCatalog()
+	catalog.Add("<b>Hello</b>", "<b>Hallo</b>")
+	catalog.Add("Hello %s", "<b>Hallo</b> %s")
+	catalog.Add("%d new", "%d neu")
+	catalog.AddPlural("%d file", "%d files", "%d Datei", "%d Dateien")
+	if err := locales.Add("test", "de", catalog); err != nil {
+		t.Fatalf("Could not add catalog: %v", err)
+	}
+	tr := locales.Translator("test", "de")
+	tests := []struct {
+		template, result string
+	}{
+		{`{{gettext "Message"}}`, "Translated Message"},
+		{`{{gettext "<b>Hello</b>"}}`, "&lt;b&gt;Hallo&lt;/b&gt;"},
+		{`{{gettextHTML "<b>Hello</b>"}}`, "<b>Hallo</b>"},
+		{`{{gettextHTML "Hello %s" "<i>"}}`, "<b>Hallo</b> &lt;i&gt;"},
+		{`{{gettextHTML "Hello %s" .}}`, "<b>Hallo</b> <i>you</i>"},
+		{`{{gettextf "%d new" 3}}`, "3 neu"},
+		{`{{ngettext "%d file" "%d files" 2}}`, "%d Dateien"},
+		{`{{ngettextf "%d file" "%d files" 1 1}}`, "1 Datei"},
+		{`{{ngettextHTML "%d file" "%d files" 2 2}}`, "2 Dateien"},
+		{`{{pgettext "Menu" "Message"}}`, "Menu Message"},
+		{`{{pgettextf "Menu" "Message"}}`, "Menu Message"},
+		{`{{npgettext "Mailbox" "Message" "Messages" 1}}`,
+			tr.ContextPlural("Mailbox", "Message", "Messages", 1)},
+		{`{{dgettext "test" "Message"}}`, "Translated Message"},
+		{`{{dngettext "other" "Message" "Messages" 2}}`, "Messages"},
+		{`{{dgettextHTML "test" "Hello %s" .}}`, "<b>Hallo</b> <i>you</i>"},
+		{`{{dngettextHTML "test" "%d file" "%d files" 1 1}}`, "1 Datei"},
+	}
+	for i, test := range tests {
+		tmpl, err := template.New("test").Funcs(FuncMap(tr)).Parse(
+			test.template)
+		if err != nil {
+			t.Errorf("Test %v: Could not parse template: %v", i, err)
+			continue
+		}
+		var buffer bytes.Buffer
+		if err := tmpl.Execute(&buffer, template.HTML("<i>you</i>")); err != nil {
+			t.Errorf("Test %v: Could not execute template: %v", i, err)
+			continue
+		}
+		if buffer.String() != test.result {
+			t.Errorf("Test %v: Result should be %q, got %q", i, test.result,
+				buffer.String())
+		}
+	}
+	var buffer bytes.Buffer
+	tmpl := template.Must(template.New("nil").Funcs(FuncMap(nil)).Parse(
+		`{{gettext "Message"}}`))
+	if err := tmpl.Execute(&buffer, nil); err != nil ||
+		buffer.String() != "Message" {
+		t.Errorf(`Nil translator should yield "Message", got %q (%v)`,
+			buffer.String(), err)
+	}
+}
@@ -0,0 +1,151 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"context"
+)
+
+// Translator translates messages using the message catalogs of a Locales for
+// a fixed domain and locale.
+//
+// A nil Translator returns all messages untranslated.
+type Translator struct {
+	locales        *Locales
+	domain, locale string
+	// key is the normalized locale.
+	key string
+	// layers are the overlay layers to consult before the base catalogs.
+	layers []string
+}
+
+// Translator loads the translation for the given domain and locale like Use
+// does and returns a Translator bound to them.
+func (l *Locales) Translator(domain, locale string) *Translator {
+	domain, locale = l.defaults(domain, locale)
+	l.Use(domain, locale)
+	return &Translator{locales: l, domain: domain, locale: locale,
+		key: localeKey(locale)}
+}
+
+// Domain returns the domain of the translator.
+func (t *Translator) Domain() string {
+	if t == nil {
+		return ""
+	}
+	return t.domain
+}
+
+// Locale returns the locale of the translator.
+func (t *Translator) Locale() string {
+	if t == nil {
+		return ""
+	}
+	return t.locale
+}
+
+// lookup returns the translation to use for the message with the given key
+// in the given domain, or nil for a nil Translator.
+func (t *Translator) lookup(domain string, key message) *translation {
+	if t == nil {
+		return nil
+	}
+	return t.locales.lookup(t.layers, domain, t.key, key)
+}
+
+// Singular returns the singular translation for the given message.
+func (t *Translator) Singular(msg string) string {
+	return t.lookup(t.Domain(), message{msg, ""}).Singular(msg)
+}
+
+// Plural returns the plural translation for the given singular and plural
+// message and the number n.
+func (t *Translator) Plural(singular, plural string, n int) string {
+	return t.lookup(t.Domain(), message{singular, plural}).Plural(singular,
+		plural, n)
+}
+
+// Plural64 is like Plural but takes an int64.
+func (t *Translator) Plural64(singular, plural string, n int64) string {
+	return t.PluralUint64(singular, plural, uint64(n))
+}
+
+// PluralUint64 is like Plural but takes an uint64.
+func (t *Translator) PluralUint64(singular, plural string, n uint64) string {
+	return t.lookup(t.Domain(), message{singular, plural}).PluralUint64(
+		singular, plural, n)
+}
+
+// PluralDecimal is like Plural but takes a decimal number like "1.50". See
+// Locales.PluralDecimal for details.
+func (t *Translator) PluralDecimal(singular, plural string,
+	number string) (string, error) {
+	return t.lookup(t.Domain(), message{singular, plural}).PluralDecimal(
+		singular, plural, number)
+}
+
+// ContextSingular is like Singular but translates the message in the given
+// context.
+func (t *Translator) ContextSingular(context, msg string) string {
+	key := message{context + contextSeparator + msg, ""}
+	return t.lookup(t.Domain(), key).ContextSingular(context, msg)
+}
+
+// ContextPlural is like Plural but translates the messages in the given
+// context.
+func (t *Translator) ContextPlural(context, singular, plural string,
+	n int) string {
+	key := message{context + contextSeparator + singular, plural}
+	return t.lookup(t.Domain(), key).ContextPlural(context, singular, plural,
+		n)
+}
+
+// DomainSingular is like Singular but uses the given domain instead of the
+// translator's one.
+//
+// You have to load the corresponding message catalogs with Use before.
+func (t *Translator) DomainSingular(domain, msg string) string {
+	return t.lookup(domain, message{msg, ""}).Singular(msg)
+}
+
+// DomainPlural is like Plural but uses the given domain instead of the
+// translator's one.
+//
+// You have to load the corresponding message catalogs with Use before.
+func (t *Translator) DomainPlural(domain, singular, plural string,
+	n int) string {
+	return t.lookup(domain, message{singular, plural}).Plural(singular,
+		plural, n)
+}
+
+// contextKey is the type of keys of values stored in a context.Context.
+type contextKey int
+
+const translatorKey contextKey = iota
+
+// NewContext returns a copy of ctx carrying the given translator.
+func NewContext(ctx context.Context, tr *Translator) context.Context {
+	return context.WithValue(ctx, translatorKey, tr)
+}
+
+// FromContext returns the translator carried by ctx, or nil if there is none.
+// As a nil Translator does not translate at all, the result may be used
+// unchecked.
+func FromContext(ctx context.Context) *Translator {
+	tr, _ := ctx.Value(translatorKey).(*Translator)
+	return tr
+}
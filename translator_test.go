@@ -0,0 +1,84 @@
+// This file is part of monsti/gettext.
+// Copyright 2013 Christian Neumann
+
+// monsti/gettext is free software: you can redistribute it and/or modify it
+// under the terms of the GNU Lesser General Public License as published by the
+// Free Software Foundation, either version 3 of the License, or (at your
+// option) any later version.
+
+// monsti/gettext is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+// FOR A PARTICULAR PURPOSE. See the GNU Lesser General Public License for more
+// details.
+
+// You should have received a copy of the GNU Lesser General Public License
+// along with monsti/gettext. If not, see <http://www.gnu.org/licenses/>.
+
+package gettext
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTranslator(t *testing.T) {
+	tr := setupLocales(t).Translator("test", "de")
+	if tr.Domain() != "test" || tr.Locale() != "de" {
+		t.Errorf(`Translator should be bound to "test" and "de", got %q and %q`,
+			tr.Domain(), tr.Locale())
+	}
+	tests := []struct {
+		ret, translated string
+	}{
+		{tr.Singular("Message"), "Translated Message"},
+		{tr.Plural("Singular", "Plural", 3), "Translated Second Plural"},
+		{tr.ContextSingular("Menu", "Message"), "Menu Message"},
+		{tr.ContextSingular("Unknown", "Message"), "Message"},
+		{tr.ContextPlural("Mailbox", "Singular", "Plural", 1),
+			"Mailbox Singular"},
+		{tr.ContextPlural("Mailbox", "Singular", "Plural", 3),
+			"Mailbox Second Plural"},
+		{tr.ContextPlural("Unknown", "Singular", "Plural", 1), "Singular"},
+		{tr.DomainSingular("test", "Message"), "Translated Message"},
+	}
+	for i, test := range tests {
+		if test.ret != test.translated {
+			t.Errorf("Test %v: Translation should be %q, got %q", i,
+				test.translated, test.ret)
+		}
+	}
+}
+
+func TestTranslatorContext(t *testing.T) {
+	ctx := context.Background()
+	if tr := FromContext(ctx); tr != nil {
+		t.Fatalf("FromContext should return nil, got %v", tr)
+	}
+	if ret := FromContext(ctx).Plural("Singular", "Plural", 1); ret != "Singular" {
+		t.Errorf(`Nil translator should return "Singular", got %q`, ret)
+	}
+	tr := setupLocales(t).Translator("test", "de")
+	if ret := FromContext(NewContext(ctx, tr)); ret != tr {
+		t.Errorf("FromContext should return %v, got %v", tr, ret)
+	}
+}
+
+func BenchmarkTranslatorSingular(b *testing.B) {
+	locales := Locales{LocaleDir: "test_locale", Fallbacks: []string{"en"}}
+	tr := locales.Translator("test", "de_DE")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Singular("Message")
+	}
+}
+
+func BenchmarkLocalesSingular(b *testing.B) {
+	locales := Locales{LocaleDir: "test_locale"}
+	locales.Use("test", "de-DE")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		locales.Singular("test", "de-DE", "Message")
+	}
+}